@@ -16,6 +16,7 @@ package htmlgo
 
 import (
 	"context"
+	"io"
 )
 
 type HTMLComponent interface {
@@ -28,6 +29,25 @@ func (f ComponentFunc) MarshalHTML(ctx context.Context) (r []byte, err error) {
 	return f(ctx)
 }
 
+func (f ComponentFunc) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	var b []byte
+	b, err = f(ctx)
+	if err != nil {
+		return
+	}
+	_, err = w.Write(b)
+	return
+}
+
+/*
+HTMLWriter is an optional interface for components that can render straight into an io.Writer.
+Fprint prefers it over MarshalHTML, so nested builders write into one shared writer instead of copying their bytes at every nesting level.
+Components that only implement MarshalHTML keep working, Fprint falls back to it.
+*/
+type HTMLWriter interface {
+	WriteHTML(ctx context.Context, w io.Writer) error
+}
+
 type MutableAttrHTMLComponent interface {
 	HTMLComponent
 	SetAttr(k string, v interface{})
@@ -0,0 +1,191 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+/*
+Asset is a javascript and css file a component needs, RequireAsset it while rendering and AssetsOutlet writes it once per page:
+
+	var flatpickr = Asset{
+		Name:       "flatpickr",
+		Script:     "/assets/flatpickr.js",
+		Stylesheet: "/assets/flatpickr.css",
+	}
+
+	var datePicker = Asset{
+		Name:     "date-picker",
+		Script:   "/assets/date-picker.js",
+		Requires: []Asset{flatpickr},
+	}
+*/
+type Asset struct {
+	// Name identifies the asset, assets with the same name are written once. Without it the urls identify the asset.
+	Name string
+	// Script is the url of a javascript file, written as <script src>
+	Script string
+	// Stylesheet is the url of a css file, written as <link rel='stylesheet'>
+	Stylesheet string
+	// Requires lists the assets written before this one. Assets with only a Name refer to an asset required somewhere else.
+	Requires []Asset
+}
+
+func (a Asset) key() string {
+	if len(a.Name) > 0 {
+		return a.Name
+	}
+	if len(a.Script) == 0 && len(a.Stylesheet) == 0 {
+		return ""
+	}
+	return a.Stylesheet + " " + a.Script
+}
+
+func (a Asset) isReference() bool {
+	return len(a.Script) == 0 && len(a.Stylesheet) == 0 && len(a.Requires) == 0
+}
+
+/*
+RequireAsset adds assets, and the assets they require, to the page being rendered, they are written once at the AssetsOutlet:
+
+	func DatePicker(name string) HTMLComponent {
+		return ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			RequireAsset(ctx, datePicker)
+			return Input(name).Type("text").Class("date-picker").MarshalHTML(ctx)
+		})
+	}
+
+Like AddHead, it does nothing outside of HTML or CollectHead, and assets required from Async content are dropped.
+*/
+func RequireAsset(ctx context.Context, assets ...Asset) {
+	c, ok := headCollectorFrom(ctx)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.assets = append(c.assets, assets...)
+}
+
+type AssetsOutletBuilder struct {
+	stylesheets bool
+	scripts     bool
+}
+
+/*
+AssetsOutlet writes the stylesheets and scripts of the required assets, dependencies first. Use two outlets to put stylesheets
+in the head and scripts at the end of the body, every file is only written by the first outlet that takes it:
+
+	HTML(
+		Head(AssetsOutlet().OnlyStylesheets()),
+		Body(page, AssetsOutlet().OnlyScripts()),
+	)
+
+Like HeadOutlet, everything after the first outlet is held back until the page is rendered.
+*/
+func AssetsOutlet() (r *AssetsOutletBuilder) {
+	return &AssetsOutletBuilder{stylesheets: true, scripts: true}
+}
+
+func (b *AssetsOutletBuilder) OnlyStylesheets() (r *AssetsOutletBuilder) {
+	b.stylesheets = true
+	b.scripts = false
+	return b
+}
+
+func (b *AssetsOutletBuilder) OnlyScripts() (r *AssetsOutletBuilder) {
+	b.stylesheets = false
+	b.scripts = true
+	return b
+}
+
+func (b *AssetsOutletBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = b.WriteHTML(ctx, buf)
+	r = buf.Bytes()
+	return
+}
+
+func (b *AssetsOutletBuilder) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	c, ok := headCollectorFrom(ctx)
+	if !ok {
+		return errors.New("htmlgo: AssetsOutlet rendered outside of HTML or CollectHead")
+	}
+	stylesheets, scripts := b.stylesheets, b.scripts
+	return c.deferOutlet(ctx, w, func(c *headCollector, ctx context.Context) ([]byte, error) {
+		return c.renderAssets(ctx, stylesheets, scripts)
+	})
+}
+
+// renderAssets writes the files of the required assets that no earlier outlet has written
+func (c *headCollector) renderAssets(ctx context.Context, stylesheets, scripts bool) (r []byte, err error) {
+	c.mu.Lock()
+	assets := orderAssets(c.assets)
+	c.mu.Unlock()
+
+	if c.writtenAssets == nil {
+		c.writtenAssets = map[string]bool{}
+	}
+	buf := bytes.NewBuffer(nil)
+	for _, a := range assets {
+		if stylesheets && len(a.Stylesheet) > 0 && !c.writtenAssets["stylesheet "+a.Stylesheet] {
+			c.writtenAssets["stylesheet "+a.Stylesheet] = true
+			err = writeHTML(ctx, buf, Link(a.Stylesheet).Rel("stylesheet"))
+			if err != nil {
+				return
+			}
+		}
+		if scripts && len(a.Script) > 0 && !c.writtenAssets["script "+a.Script] {
+			c.writtenAssets["script "+a.Script] = true
+			err = writeHTML(ctx, buf, Tag("script").Attr("src", a.Script))
+			if err != nil {
+				return
+			}
+		}
+	}
+	r = buf.Bytes()
+	return
+}
+
+// orderAssets lists the assets with every one after the assets it requires, each once
+func orderAssets(assets []Asset) (r []Asset) {
+	// a dependency can be given by name only, so find the full definitions first
+	defs := map[string]Asset{}
+	var define func(as []Asset)
+	define = func(as []Asset) {
+		for _, a := range as {
+			k := a.key()
+			if def, ok := defs[k]; len(k) > 0 && (!ok || def.isReference()) {
+				defs[k] = a
+			}
+			define(a.Requires)
+		}
+	}
+	define(assets)
+
+	visited := map[string]bool{}
+	var visit func(a Asset)
+	visit = func(a Asset) {
+		k := a.key()
+		if len(k) > 0 {
+			if visited[k] {
+				return
+			}
+			// marked before its dependencies, so a cycle ends here
+			visited[k] = true
+			a = defs[k]
+		}
+		for _, dep := range a.Requires {
+			visit(dep)
+		}
+		if len(k) > 0 {
+			r = append(r, a)
+		}
+	}
+	for _, a := range assets {
+		visit(a)
+	}
+	return
+}
@@ -0,0 +1,121 @@
+package htmlgo_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+var (
+	jquery     = Asset{Name: "jquery", Script: "/jquery.js"}
+	flatpickr  = Asset{Name: "flatpickr", Script: "/flatpickr.js", Stylesheet: "/flatpickr.css", Requires: []Asset{jquery}}
+	datePicker = Asset{Name: "date-picker", Script: "/date-picker.js", Requires: []Asset{flatpickr, {Name: "theme"}}}
+	theme      = Asset{Name: "theme", Stylesheet: "/theme.css"}
+)
+
+func requiring(tag string, assets ...Asset) HTMLComponent {
+	return ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		RequireAsset(ctx, assets...)
+		return Tag(tag).MarshalHTML(ctx)
+	})
+}
+
+func TestAssetsOutlet(t *testing.T) {
+	var cases = []struct {
+		name     string
+		comp     HTMLComponent
+		expected string
+	}{
+		{
+			name: "one outlet",
+			comp: CollectHead(
+				Head(AssetsOutlet()),
+				Body(
+					requiring("date-picker", datePicker),
+					requiring("date-picker", datePicker, jquery),
+					requiring("theme", theme),
+				),
+			),
+			expected: `
+<head>
+<script src='/jquery.js'></script>
+
+<link href='/flatpickr.css' rel='stylesheet'>
+
+<script src='/flatpickr.js'></script>
+
+<link href='/theme.css' rel='stylesheet'>
+
+<script src='/date-picker.js'></script>
+</head>
+
+<body>
+<date-picker></date-picker>
+
+<date-picker></date-picker>
+
+<theme></theme>
+</body>
+`,
+		},
+		{
+			name: "stylesheets in head, scripts in body",
+			comp: CollectHead(
+				Head(AssetsOutlet().OnlyStylesheets()),
+				Body(
+					requiring("date-picker", datePicker),
+					AssetsOutlet().OnlyScripts(),
+					AssetsOutlet(),
+				),
+			),
+			expected: `
+<head>
+<link href='/flatpickr.css' rel='stylesheet'>
+</head>
+
+<body>
+<date-picker></date-picker>
+
+<script src='/jquery.js'></script>
+
+<script src='/flatpickr.js'></script>
+
+<script src='/date-picker.js'></script>
+</body>
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			diff := testingutils.PrettyJsonDiff(c.expected, MustString(c.comp, context.TODO()))
+			if len(diff) > 0 {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAssetsOutletCycle(t *testing.T) {
+	a := Asset{Name: "a", Script: "/a.js", Requires: []Asset{{Name: "b"}}}
+	b := Asset{Name: "b", Script: "/b.js", Requires: []Asset{a}}
+	comp := CollectHead(AssetsOutlet(), requiring("x", a, b))
+
+	expected := `
+<script src='/b.js'></script>
+
+<script src='/a.js'></script>
+
+<x></x>
+`
+	diff := testingutils.PrettyJsonDiff(expected, MustString(comp, context.TODO()))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+
+	if _, err := AssetsOutlet().MarshalHTML(context.TODO()); err == nil {
+		t.Error("expected an error for an outlet outside of HTML")
+	}
+}
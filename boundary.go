@@ -0,0 +1,146 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// PanicError is the error an ErrorBoundary reports for a panic in its subtree, with the stack of the panicking goroutine.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("htmlgo: component panicked: %v", e.Value)
+}
+
+type errorHookContextKey int
+
+const errorHookKey errorHookContextKey = iota
+
+// WithErrorHook returns a ctx that makes the ErrorBoundary components rendered with it report their errors to hook, unless they have their own OnError.
+func WithErrorHook(ctx context.Context, hook func(ctx context.Context, err error)) context.Context {
+	return context.WithValue(ctx, errorHookKey, hook)
+}
+
+type ErrorBoundaryBuilder struct {
+	child    HTMLComponent
+	fallback func(ctx context.Context, err error) HTMLComponent
+	onError  func(ctx context.Context, err error)
+}
+
+/*
+ErrorBoundary renders child, and if child returns an error or panics, renders fallback in its place instead of failing the page:
+
+	Div(
+		ErrorBoundary(salesWidget, func(ctx context.Context, err error) HTMLComponent {
+			return Div(Text("Sales are not available right now")).Class("widget-error")
+		}),
+		ordersWidget,
+	).Class("dashboard")
+
+Child is rendered into a buffer first, so nothing of it is written when it fails, and a Flush() inside it does not flush.
+What it adds with AddHead, RequireAsset and ScopedStyle, and the Async it starts, are likewise only kept once it rendered.
+A nil fallback writes nothing. Errors of the fallback itself, and errors after ctx is done, are returned as they are.
+The error, a *PanicError for a panic, is reported to OnError, or else to the hook set with WithErrorHook.
+*/
+func ErrorBoundary(child HTMLComponent, fallback func(ctx context.Context, err error) HTMLComponent) (r *ErrorBoundaryBuilder) {
+	return &ErrorBoundaryBuilder{
+		child:    child,
+		fallback: fallback,
+	}
+}
+
+func (b *ErrorBoundaryBuilder) OnError(f func(ctx context.Context, err error)) (r *ErrorBoundaryBuilder) {
+	b.onError = f
+	return b
+}
+
+func (b *ErrorBoundaryBuilder) GetChildren() (r []HTMLComponent) {
+	return []HTMLComponent{b.child}
+}
+
+func (b *ErrorBoundaryBuilder) ReplaceChildren(children []HTMLComponent) {
+	b.child = nil
+	if len(children) == 1 {
+		b.child = children[0]
+	} else if len(children) > 1 {
+		b.child = HTMLComponents(children)
+	}
+}
+
+func (b *ErrorBoundaryBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = b.WriteHTML(ctx, buf)
+	r = buf.Bytes()
+	return
+}
+
+func (b *ErrorBoundaryBuilder) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	childCtx, commit := stageSideEffects(ctx)
+	buf := bytes.NewBuffer(nil)
+	err = b.renderChild(childCtx, buf)
+	if err == nil {
+		commit()
+		_, err = w.Write(buf.Bytes())
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	b.report(ctx, err)
+	if b.fallback == nil {
+		return nil
+	}
+	return writeHTML(ctx, w, b.fallback(ctx, err))
+}
+
+// stageSideEffects returns a ctx that holds back what the child adds with AddHead, RequireAsset, ScopedStyle and Async,
+// and a commit that keeps it, so a failed child leaves nothing behind
+func stageSideEffects(ctx context.Context) (r context.Context, commit func()) {
+	r = ctx
+	var commits []func()
+	if c, ok := headCollectorFrom(ctx); ok {
+		staged := c.stage()
+		r = context.WithValue(r, headCollectorKey, staged)
+		commits = append(commits, func() {
+			c.commit(staged)
+		})
+	}
+	if reg, _ := ctx.Value(asyncRegistryKey).(*asyncRegistry); reg != nil {
+		parent, _ := ctx.Value(asyncStageKey).(*asyncStage)
+		stage := &asyncStage{parent: parent}
+		r = context.WithValue(r, asyncStageKey, stage)
+		commits = append(commits, stage.commit)
+	}
+	commit = func() {
+		for _, c := range commits {
+			c()
+		}
+	}
+	return
+}
+
+func (b *ErrorBoundaryBuilder) renderChild(ctx context.Context, w io.Writer) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &PanicError{Value: p, Stack: debug.Stack()}
+		}
+	}()
+	return writeHTML(ctx, w, b.child)
+}
+
+func (b *ErrorBoundaryBuilder) report(ctx context.Context, err error) {
+	if b.onError != nil {
+		b.onError(ctx, err)
+		return
+	}
+	if hook, ok := ctx.Value(errorHookKey).(func(ctx context.Context, err error)); ok && hook != nil {
+		hook(ctx, err)
+	}
+}
@@ -0,0 +1,134 @@
+package htmlgo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func TestErrorBoundary(t *testing.T) {
+	failing := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("sales service down")
+	})
+	panicking := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		panic("nil map")
+	})
+	fallback := func(ctx context.Context, err error) HTMLComponent {
+		return Div(Text(err.Error())).Class("widget-error")
+	}
+
+	var reported []string
+	ctx := WithErrorHook(context.TODO(), func(ctx context.Context, err error) {
+		reported = append(reported, "hook: "+err.Error())
+	})
+
+	comp := Div(
+		ErrorBoundary(Div(Text("partial"), failing), fallback),
+		ErrorBoundary(panicking, fallback).OnError(func(ctx context.Context, err error) {
+			var pe *PanicError
+			if errors.As(err, &pe) && len(pe.Stack) > 0 {
+				reported = append(reported, "own: "+err.Error())
+			}
+		}),
+		ErrorBoundary(failing, nil),
+		ErrorBoundary(Span("fine"), fallback),
+	).Class("dashboard")
+
+	expected := `
+<div class='dashboard'>
+<div class='widget-error'>sales service down</div>
+
+<div class='widget-error'>htmlgo: component panicked: nil map</div>
+
+<span>fine</span>
+</div>
+`
+	diff := testingutils.PrettyJsonDiff(expected, MustString(comp, ctx))
+	diff += testingutils.PrettyJsonDiff([]string{
+		"hook: sales service down",
+		"own: htmlgo: component panicked: nil map",
+		"hook: sales service down",
+	}, reported)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestErrorBoundaryPassesThrough(t *testing.T) {
+	failingFallback := ErrorBoundary(
+		ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			return nil, errors.New("child")
+		}),
+		func(ctx context.Context, err error) HTMLComponent {
+			return ComponentFunc(func(ctx context.Context) ([]byte, error) {
+				return nil, errors.New("fallback")
+			})
+		},
+	)
+	if _, err := failingFallback.MarshalHTML(context.TODO()); err == nil || err.Error() != "fallback" {
+		t.Errorf("expected the fallback error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	canceled := ErrorBoundary(
+		ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			return nil, ctx.Err()
+		}),
+		func(ctx context.Context, err error) HTMLComponent {
+			return Text("fallback")
+		},
+	)
+	if _, err := canceled.MarshalHTML(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestErrorBoundaryDropsSideEffects(t *testing.T) {
+	card := ScopedStyle("card", `.title{color:red}`)
+	failing := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		AddHead(ctx, Meta().Name("description").Content("from the failed widget"))
+		RequireAsset(ctx, Asset{Name: "chart", Script: "/chart.js"})
+		return nil, errors.New("down")
+	})
+
+	page := HTML(
+		Head(HeadOutlet(), AssetsOutlet()),
+		Body(
+			ErrorBoundary(card.Apply(Div(Text("broken")), failing), nil),
+			card.Apply(Div(Text("healthy"))),
+		),
+	)
+	out := MustString(page, context.TODO())
+	if !strings.Contains(out, "{color:red}</style>") {
+		t.Errorf("healthy card rendered without its stylesheet: %s", out)
+	}
+	for _, dropped := range []string{"from the failed widget", "/chart.js", "broken"} {
+		if strings.Contains(out, dropped) {
+			t.Errorf("failed boundary left %q behind: %s", dropped, out)
+		}
+	}
+
+	started := false
+	buf := bytes.NewBuffer(nil)
+	err := Stream(buf, Body(
+		ErrorBoundary(HTMLComponents{
+			Async(Text("loading"), func(ctx context.Context) HTMLComponent {
+				started = true
+				return Text("panel")
+			}),
+			failing,
+		}, nil),
+	), context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started || strings.Contains(buf.String(), "<template") {
+		t.Errorf("Async of a failed boundary was started: %s", buf.String())
+	}
+}
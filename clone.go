@@ -0,0 +1,101 @@
+package htmlgo
+
+/*
+Clone returns a deep copy of the builder: attrs, classes, styles and child builders are copied, so a base element can be customised
+per use without changing the original. Attribute values and children that are not builders, like ComponentFunc, are shared.
+
+	row := Tr().Class("row")
+	for _, u := range users {
+		rows = append(rows, row.Clone().Attr("data-id", u.ID).Children(Td(Text(u.Name))))
+	}
+*/
+func (b *HTMLTagBuilder) Clone() (r *HTMLTagBuilder) {
+	r = &HTMLTagBuilder{
+		tag:        b.tag,
+		omitEndTag: b.omitEndTag,
+		attrs:      make([]*tagAttr, 0, len(b.attrs)),
+	}
+	for _, at := range b.attrs {
+		r.attrs = append(r.attrs, &tagAttr{at.key, at.value})
+	}
+	r.styles = append(r.styles, b.styles...)
+	r.classNames = append(r.classNames, b.classNames...)
+	r.children = cloneComponents(b.children)
+	return
+}
+
+func (hcs HTMLComponents) Clone() (r HTMLComponents) {
+	return cloneComponents(hcs)
+}
+
+func (b *IfBuilder) Clone() (r *IfBuilder) {
+	return &IfBuilder{
+		comps: cloneComponents(b.comps),
+		set:   b.set,
+	}
+}
+
+func (b *IfFuncBuilder) Clone() (r *IfFuncBuilder) {
+	return &IfFuncBuilder{
+		f:   b.f,
+		set: b.set,
+	}
+}
+
+func (b *ParallelBuilder) Clone() (r *ParallelBuilder) {
+	return &ParallelBuilder{
+		children: cloneComponents(b.children),
+		limit:    b.limit,
+	}
+}
+
+func cloneComponents(comps []HTMLComponent) (r []HTMLComponent) {
+	if comps == nil {
+		return
+	}
+	r = make([]HTMLComponent, 0, len(comps))
+	for _, c := range comps {
+		r = append(r, cloneComponent(c))
+	}
+	return
+}
+
+func cloneComponent(c HTMLComponent) (r HTMLComponent) {
+	switch v := c.(type) {
+	case *HTMLTagBuilder:
+		if v != nil {
+			return v.Clone()
+		}
+	case HTMLComponents:
+		return v.Clone()
+	case *IfBuilder:
+		if v != nil {
+			return v.Clone()
+		}
+	case *IfFuncBuilder:
+		if v != nil {
+			return v.Clone()
+		}
+	case *ParallelBuilder:
+		if v != nil {
+			return v.Clone()
+		}
+	case compactComponents:
+		return compactComponents(cloneComponents(v))
+	case headScope:
+		return headScope(cloneComponents(v))
+	case *scopedComponents:
+		if v != nil {
+			return &scopedComponents{style: v.style, children: cloneComponents(v.children)}
+		}
+	case *providerComponents:
+		if v != nil {
+			return &providerComponents{provide: v.provide, children: cloneComponents(v.children)}
+		}
+	case *ErrorBoundaryBuilder:
+		if v != nil {
+			return &ErrorBoundaryBuilder{child: cloneComponent(v.child), fallback: v.fallback, onError: v.onError}
+		}
+	}
+	return c
+}
@@ -0,0 +1,60 @@
+package htmlgo_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func TestClone(t *testing.T) {
+	shared := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		return []byte("shared"), nil
+	})
+	base := Div(
+		Span("label").Class("label"),
+		If(true, B("if")),
+		Components(I("in components")),
+		shared,
+	).Class("card").Style("color:red").Attr("data-id", "base")
+	expected := MustString(base, context.TODO())
+
+	c := base.Clone()
+	c.Attr("data-id", "clone").Class("clone").Style("margin:0").AppendChildren(Text("more"))
+	c.GetChildren()[0].(*HTMLTagBuilder).Text("changed").Class("x")
+	c.GetChildren()[2].(HTMLComponents)[0].(*HTMLTagBuilder).Id("i")
+
+	diff := testingutils.PrettyJsonDiff(expected, MustString(base, context.TODO()))
+	if len(diff) > 0 {
+		t.Error("base changed", diff)
+	}
+
+	expectedClone := `
+<div data-id='clone' class='card clone' style='color:red; margin:0;'>
+<span class='label x'>changed</span>
+
+<b>if</b>
+
+<i id='i'>in components</i>
+shared` + "more</div>\n"
+	diff = testingutils.PrettyJsonDiff(expectedClone, MustString(c, context.TODO()))
+	if len(diff) > 0 {
+		t.Error("clone", diff)
+	}
+}
+
+func TestCloneIf(t *testing.T) {
+	b := If(false, Div()).Else(Span("else"))
+	c := b.Clone()
+	c.ElseIf(true, Div())
+
+	if MustString(b, context.TODO()) != MustString(c, context.TODO()) {
+		t.Error("clone of a set IfBuilder should render the same branch")
+	}
+
+	f := Iff(true, func() HTMLComponent { return Text("f") }).Clone()
+	if MustString(f, context.TODO()) != "f" {
+		t.Error("unexpected IfFuncBuilder clone output")
+	}
+}
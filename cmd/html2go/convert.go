@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// the longest children list kept on the line of its parent call
+const maxInlineWidth = 60
+
+type call struct {
+	name     string
+	args     []string
+	children []*expr
+}
+
+// expr is a chain of calls, like Div(...).Class("a")
+type expr struct {
+	calls []call
+}
+
+func (e *expr) chain(name string, args ...string) {
+	e.calls = append(e.calls, call{name: "." + name, args: args})
+}
+
+func (e *expr) render(indent int) string {
+	var b strings.Builder
+	for _, c := range e.calls {
+		b.WriteString(c.name + "(")
+		if len(c.children) > 0 {
+			b.WriteString(renderChildren(c.children, indent))
+		} else {
+			b.WriteString(strings.Join(c.args, ", "))
+		}
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+func renderChildren(children []*expr, indent int) string {
+	var inline []string
+	for _, c := range children {
+		inline = append(inline, c.render(indent))
+	}
+	joined := strings.Join(inline, ", ")
+	if len(joined) <= maxInlineWidth && !strings.Contains(joined, "\n") {
+		return joined
+	}
+
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, c := range children {
+		b.WriteString(strings.Repeat("\t", indent+1) + c.render(indent+1) + ",\n")
+	}
+	b.WriteString(strings.Repeat("\t", indent))
+	return b.String()
+}
+
+// convert reads html markup and returns the htmlgo Go expression building it
+func convert(r io.Reader) (code string, err error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+
+	var nodes []*html.Node
+	if isFullDocument(src) {
+		var doc *html.Node
+		doc, err = html.ParseWithOptions(bytes.NewReader(src), html.ParseOptionEnableScripting(false))
+		if err != nil {
+			return
+		}
+		for c := doc.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, c)
+		}
+	} else {
+		// scripting is disabled so noscript content comes as elements, not text
+		nodes, err = html.ParseFragmentWithOptions(bytes.NewReader(src), &html.Node{
+			Type:     html.ElementNode,
+			Data:     "body",
+			DataAtom: atom.Body,
+		}, html.ParseOptionEnableScripting(false))
+		if err != nil {
+			return
+		}
+	}
+
+	exprs := convertNodes(nodes, false)
+	switch len(exprs) {
+	case 0:
+		return "Components()", nil
+	case 1:
+		return exprs[0].render(0), nil
+	}
+	return (&expr{calls: []call{{name: "Components", children: exprs}}}).render(0), nil
+}
+
+func isFullDocument(src []byte) bool {
+	s := strings.ToLower(string(bytes.TrimSpace(src)))
+	return strings.HasPrefix(s, "<!doctype") || strings.HasPrefix(s, "<html")
+}
+
+func convertNodes(nodes []*html.Node, preserve bool) (r []*expr) {
+	for i, n := range nodes {
+		switch n.Type {
+		case html.TextNode:
+			t := n.Data
+			if !preserve {
+				t = collapseText(t, i == 0, i == len(nodes)-1)
+			}
+			if len(t) == 0 {
+				continue
+			}
+			r = append(r, &expr{calls: []call{{name: "Text", args: []string{strconv.Quote(t)}}}})
+		case html.ElementNode:
+			r = append(r, convertElement(n))
+		}
+		// comments and doctypes are dropped, HTML() writes the doctype
+	}
+	return
+}
+
+// collapseText collapses whitespace like browsers do, dropping whitespace that only lays out the markup
+func collapseText(t string, first, last bool) string {
+	if len(strings.TrimSpace(t)) == 0 {
+		if first || last || strings.ContainsAny(t, "\r\n") {
+			return ""
+		}
+		return " "
+	}
+	r := strings.Join(strings.Fields(t), " ")
+	if !first && isSpace(t[0]) {
+		r = " " + r
+	}
+	if !last && isSpace(t[len(t)-1]) {
+		r = r + " "
+	}
+	return r
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func children(n *html.Node) (r []*html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r = append(r, c)
+	}
+	return
+}
+
+func textContent(n *html.Node) (t string, ok bool) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.TextNode {
+			return "", false
+		}
+		t += c.Data
+	}
+	return t, true
+}
+
+var preserveTags = map[string]bool{
+	"pre":      true,
+	"textarea": true,
+	"script":   true,
+	"style":    true,
+}
+
+func convertElement(n *html.Node) (e *expr) {
+	e = &expr{}
+	tag := n.Data
+	attrs := n.Attr
+	preserve := preserveTags[tag]
+	kids := children(n)
+
+	cons, known := constructors[tag]
+	if tag == "html" && len(attrs) > 0 {
+		// HTML() can not take attributes
+		known = false
+	}
+	if !known {
+		e.calls = append(e.calls, call{name: "Tag", args: []string{strconv.Quote(tag)}})
+		addAttrs(e, attrs)
+		addChildren(e, kids, preserve)
+		return
+	}
+
+	switch cons.kind {
+	case childrenConstructor:
+		e.calls = append(e.calls, call{name: cons.name, children: convertNodes(kids, preserve)})
+		addAttrs(e, attrs)
+	case voidConstructor:
+		e.calls = append(e.calls, call{name: cons.name})
+		addAttrs(e, attrs)
+	case attrConstructor:
+		var rest []html.Attribute
+		value := ""
+		for _, a := range attrs {
+			if a.Key == cons.attr && len(a.Namespace) == 0 {
+				value = a.Val
+				continue
+			}
+			rest = append(rest, a)
+		}
+		e.calls = append(e.calls, call{name: cons.name, args: []string{strconv.Quote(value)}})
+		addAttrs(e, rest)
+		addChildren(e, kids, preserve)
+	case textConstructor:
+		text, onlyText := textContent(n)
+		if !preserve {
+			text = collapseText(text, true, true)
+		}
+		if !onlyText {
+			text = ""
+		}
+		e.calls = append(e.calls, call{name: cons.name, args: []string{strconv.Quote(text)}})
+		addAttrs(e, attrs)
+		if !onlyText {
+			addChildren(e, kids, preserve)
+		}
+	}
+	return
+}
+
+func addChildren(e *expr, kids []*html.Node, preserve bool) {
+	if cs := convertNodes(kids, preserve); len(cs) > 0 {
+		e.calls = append(e.calls, call{name: ".Children", children: cs})
+	}
+}
+
+func addAttrs(e *expr, attrs []html.Attribute) {
+	for _, a := range attrs {
+		key := a.Key
+		if len(a.Namespace) > 0 {
+			key = a.Namespace + ":" + key
+		}
+		val := strconv.Quote(a.Val)
+
+		if m, ok := attrMethods[key]; ok {
+			switch m.kind {
+			case stringAttrMethod:
+				e.chain(m.name, val)
+				continue
+			case boolAttrMethod:
+				e.chain(m.name, "true")
+				continue
+			case intAttrMethod:
+				if _, err := strconv.Atoi(a.Val); err == nil {
+					e.chain(m.name, a.Val)
+					continue
+				}
+			}
+		}
+
+		switch {
+		case key == "class":
+			e.chain("Class", val)
+		case key == "style":
+			e.chain("Style", val)
+		case strings.HasPrefix(key, "on"):
+			// handlers from markup are trusted code, plain strings are refused in event handlers
+			e.chain("Attr", strconv.Quote(key), "SafeJS("+val+")")
+		case strings.HasPrefix(key, "data-"):
+			e.chain("Data", strconv.Quote(strings.TrimPrefix(key, "data-")), val)
+		case len(a.Val) == 0:
+			e.chain("Attr", strconv.Quote(key), "true")
+		default:
+			e.chain("Attr", strconv.Quote(key), val)
+		}
+	}
+}
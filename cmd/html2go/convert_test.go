@@ -0,0 +1,141 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/theplant/testingutils"
+)
+
+var convertCases = []struct {
+	name     string
+	input    string
+	expected string
+}{
+	{
+		name:     "simple",
+		input:    `<div class="a"><h1>Hi</h1></div>`,
+		expected: `Div(H1("Hi")).Class("a")`,
+	},
+	{
+		name: "typed attributes and constructors",
+		input: `<form action="/login" method="post">
+	<label for="u">User</label>
+	<input id="u" name="user" type="text" required tabindex="1" autofocus>
+	<img src="/logo.png" alt="Logo" data-size="s">
+	<button type="submit" disabled>Log <b>in</b></button>
+</form>`,
+		expected: `Form(
+	Label("User").For("u"),
+	Input("user").Id("u").Type("text").Required(true).TabIndex(1).Attr("autofocus", true),
+	Img("/logo.png").Alt("Logo").Data("size", "s"),
+	Button("").Type("submit").Disabled(true).Children(Text("Log "), B("in")),
+).Action("/login").Method("post")`,
+	},
+	{
+		name:     "event handlers",
+		input:    `<a href="#" onclick="toggle()">Menu</a>`,
+		expected: `A(Text("Menu")).Href("#").Attr("onclick", SafeJS("toggle()"))`,
+	},
+	{
+		name:     "noscript content",
+		input:    `<noscript><iframe src="https://gtm/ns" height="0"></iframe></noscript>`,
+		expected: `Noscript(Iframe().Src("https://gtm/ns").Attr("height", "0"))`,
+	},
+	{
+		name:     "whitespace sensitive content",
+		input:    "<pre>  a\n  b</pre><script>var a = \"<b>\";</script>",
+		expected: "Components(Pre(\"  a\\n  b\"), Script(\"var a = \\\"<b>\\\";\"))",
+	},
+	{
+		name:  "full document",
+		input: `<!DOCTYPE html><html><head><meta charset="utf8"><title>T</title></head><body><custom-el x="1">c</custom-el></body></html>`,
+		expected: `HTML(
+	Head(Meta().Charset("utf8"), Title("T")),
+	Body(Tag("custom-el").Attr("x", "1").Children(Text("c"))),
+)`,
+	},
+}
+
+func TestConvert(t *testing.T) {
+	for _, c := range convertCases {
+		code, err := convert(strings.NewReader(c.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := testingutils.PrettyJsonDiff(c.expected, code)
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+}
+
+// TestConstructorsMatchElements reads the constructors of elements.go, so the table can not drift from the package
+func TestConstructorsMatchElements(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "../../elements.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]constructor{}
+	for _, d := range f.Decls {
+		fd, ok := d.(*ast.FuncDecl)
+		if !ok || fd.Recv != nil || !fd.Name.IsExported() || fd.Name.Name == "ScriptJSON" {
+			continue
+		}
+		var param string
+		if len(fd.Type.Params.List) > 0 {
+			param = fd.Type.Params.List[0].Names[0].Name
+		}
+
+		var tag string
+		c := constructor{name: fd.Name.Name, kind: voidConstructor}
+		if len(param) > 0 {
+			c.kind = childrenConstructor
+		}
+		ast.Inspect(fd.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			var name string
+			switch fn := call.Fun.(type) {
+			case *ast.Ident:
+				name = fn.Name
+			case *ast.SelectorExpr:
+				name = fn.Sel.Name
+			}
+			switch {
+			case name == "Tag":
+				tag, _ = strconv.Unquote(call.Args[0].(*ast.BasicLit).Value)
+			case name == "Text" && isIdent(call.Args[0], param), name == "escapeRawText":
+				c.kind = textConstructor
+			case name == "Attr" && len(call.Args) == 2 && isIdent(call.Args[1], param):
+				c.kind = attrConstructor
+				c.attr, _ = strconv.Unquote(call.Args[0].(*ast.BasicLit).Value)
+			}
+			return true
+		})
+		found[tag] = c
+	}
+
+	for tag, c := range found {
+		if constructors[tag] != c {
+			t.Errorf("%s: elements.go has %#+v, table has %#+v", tag, c, constructors[tag])
+		}
+	}
+	for tag := range constructors {
+		if _, ok := found[tag]; !ok {
+			t.Errorf("%s: no constructor in elements.go", tag)
+		}
+	}
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == name
+}
@@ -0,0 +1,179 @@
+package main
+
+type constructorKind int
+
+const (
+	// Div(children ...HTMLComponent)
+	childrenConstructor constructorKind = iota
+	// H1(text string)
+	textConstructor
+	// Br()
+	voidConstructor
+	// Img(src string), the argument is an attribute value
+	attrConstructor
+)
+
+type constructor struct {
+	name string
+	kind constructorKind
+	// the attribute an attrConstructor argument is set to
+	attr string
+}
+
+// constructors maps tag names to the element constructors of elements.go, TestConstructorsMatchElements keeps them in sync
+var constructors = map[string]constructor{
+	"a":          {name: "A", kind: childrenConstructor},
+	"abbr":       {name: "Abbr", kind: textConstructor},
+	"address":    {name: "Address", kind: childrenConstructor},
+	"area":       {name: "Area", kind: voidConstructor},
+	"article":    {name: "Article", kind: childrenConstructor},
+	"aside":      {name: "Aside", kind: childrenConstructor},
+	"audio":      {name: "Audio", kind: childrenConstructor},
+	"b":          {name: "B", kind: textConstructor},
+	"base":       {name: "Base", kind: voidConstructor},
+	"bdi":        {name: "Bdi", kind: textConstructor},
+	"bdo":        {name: "Bdo", kind: textConstructor},
+	"blockquote": {name: "Blockquote", kind: childrenConstructor},
+	"body":       {name: "Body", kind: childrenConstructor},
+	"br":         {name: "Br", kind: voidConstructor},
+	"button":     {name: "Button", kind: textConstructor},
+	"canvas":     {name: "Canvas", kind: childrenConstructor},
+	"caption":    {name: "Caption", kind: textConstructor},
+	"cite":       {name: "Cite", kind: childrenConstructor},
+	"code":       {name: "Code", kind: textConstructor},
+	"col":        {name: "Col", kind: voidConstructor},
+	"colgroup":   {name: "Colgroup", kind: childrenConstructor},
+	"data":       {name: "Data", kind: childrenConstructor},
+	"datalist":   {name: "Datalist", kind: childrenConstructor},
+	"dd":         {name: "Dd", kind: childrenConstructor},
+	"del":        {name: "Del", kind: textConstructor},
+	"details":    {name: "Details", kind: childrenConstructor},
+	"dfn":        {name: "Dfn", kind: textConstructor},
+	"dialog":     {name: "Dialog", kind: childrenConstructor},
+	"div":        {name: "Div", kind: childrenConstructor},
+	"dl":         {name: "Dl", kind: childrenConstructor},
+	"dt":         {name: "Dt", kind: childrenConstructor},
+	"em":         {name: "Em", kind: textConstructor},
+	"embed":      {name: "Embed", kind: voidConstructor},
+	"fieldset":   {name: "Fieldset", kind: childrenConstructor},
+	"figcaption": {name: "Figcaption", kind: textConstructor},
+	"figure":     {name: "Figure", kind: childrenConstructor},
+	"footer":     {name: "Footer", kind: childrenConstructor},
+	"form":       {name: "Form", kind: childrenConstructor},
+	"h1":         {name: "H1", kind: textConstructor},
+	"h2":         {name: "H2", kind: textConstructor},
+	"h3":         {name: "H3", kind: textConstructor},
+	"h4":         {name: "H4", kind: textConstructor},
+	"h5":         {name: "H5", kind: textConstructor},
+	"h6":         {name: "H6", kind: textConstructor},
+	"head":       {name: "Head", kind: childrenConstructor},
+	"header":     {name: "Header", kind: childrenConstructor},
+	"hgroup":     {name: "Hgroup", kind: childrenConstructor},
+	"hr":         {name: "Hr", kind: voidConstructor},
+	"html":       {name: "HTML", kind: childrenConstructor},
+	"i":          {name: "I", kind: textConstructor},
+	"iframe":     {name: "Iframe", kind: childrenConstructor},
+	"img":        {name: "Img", kind: attrConstructor, attr: "src"},
+	"input":      {name: "Input", kind: attrConstructor, attr: "name"},
+	"ins":        {name: "Ins", kind: childrenConstructor},
+	"kbd":        {name: "Kbd", kind: textConstructor},
+	"label":      {name: "Label", kind: textConstructor},
+	"legend":     {name: "Legend", kind: textConstructor},
+	"li":         {name: "Li", kind: childrenConstructor},
+	"link":       {name: "Link", kind: attrConstructor, attr: "href"},
+	"main":       {name: "Main", kind: childrenConstructor},
+	"map":        {name: "Map", kind: childrenConstructor},
+	"mark":       {name: "Mark", kind: textConstructor},
+	"menu":       {name: "Menu", kind: childrenConstructor},
+	"meta":       {name: "Meta", kind: voidConstructor},
+	"meter":      {name: "Meter", kind: childrenConstructor},
+	"nav":        {name: "Nav", kind: childrenConstructor},
+	"noscript":   {name: "Noscript", kind: childrenConstructor},
+	"object":     {name: "Object", kind: attrConstructor, attr: "data"},
+	"ol":         {name: "Ol", kind: childrenConstructor},
+	"optgroup":   {name: "Optgroup", kind: childrenConstructor},
+	"option":     {name: "Option", kind: textConstructor},
+	"output":     {name: "Output", kind: childrenConstructor},
+	"p":          {name: "P", kind: childrenConstructor},
+	"param":      {name: "Param", kind: attrConstructor, attr: "name"},
+	"picture":    {name: "Picture", kind: childrenConstructor},
+	"pre":        {name: "Pre", kind: textConstructor},
+	"progress":   {name: "Progress", kind: childrenConstructor},
+	"q":          {name: "Q", kind: textConstructor},
+	"rp":         {name: "Rp", kind: textConstructor},
+	"rt":         {name: "Rt", kind: textConstructor},
+	"ruby":       {name: "Ruby", kind: childrenConstructor},
+	"s":          {name: "S", kind: textConstructor},
+	"samp":       {name: "Samp", kind: childrenConstructor},
+	"script":     {name: "Script", kind: textConstructor},
+	"section":    {name: "Section", kind: childrenConstructor},
+	"select":     {name: "Select", kind: childrenConstructor},
+	"slot":       {name: "Slot", kind: childrenConstructor},
+	"small":      {name: "Small", kind: textConstructor},
+	"source":     {name: "Source", kind: attrConstructor, attr: "src"},
+	"span":       {name: "Span", kind: textConstructor},
+	"strong":     {name: "Strong", kind: textConstructor},
+	"style":      {name: "Style", kind: textConstructor},
+	"sub":        {name: "Sub", kind: textConstructor},
+	"summary":    {name: "Summary", kind: childrenConstructor},
+	"sup":        {name: "Sup", kind: textConstructor},
+	"table":      {name: "Table", kind: childrenConstructor},
+	"tbody":      {name: "Tbody", kind: childrenConstructor},
+	"td":         {name: "Td", kind: childrenConstructor},
+	"template":   {name: "Template", kind: childrenConstructor},
+	"textarea":   {name: "Textarea", kind: textConstructor},
+	"tfoot":      {name: "Tfoot", kind: childrenConstructor},
+	"th":         {name: "Th", kind: textConstructor},
+	"thead":      {name: "Thead", kind: childrenConstructor},
+	"time":       {name: "Time", kind: attrConstructor, attr: "datetime"},
+	"title":      {name: "Title", kind: textConstructor},
+	"tr":         {name: "Tr", kind: childrenConstructor},
+	"track":      {name: "Track", kind: attrConstructor, attr: "src"},
+	"u":          {name: "U", kind: textConstructor},
+	"ul":         {name: "Ul", kind: childrenConstructor},
+	"var":        {name: "Var", kind: textConstructor},
+	"video":      {name: "Video", kind: childrenConstructor},
+	"wbr":        {name: "Wbr", kind: voidConstructor},
+}
+
+type attrMethodKind int
+
+const (
+	stringAttrMethod attrMethodKind = iota
+	intAttrMethod
+	boolAttrMethod
+)
+
+type attrMethod struct {
+	name string
+	kind attrMethodKind
+}
+
+// attrMethods maps attribute names to the typed setters of HTMLTagBuilder
+var attrMethods = map[string]attrMethod{
+	"id":          {"Id", stringAttrMethod},
+	"href":        {"Href", stringAttrMethod},
+	"rel":         {"Rel", stringAttrMethod},
+	"title":       {"Title", stringAttrMethod},
+	"tabindex":    {"TabIndex", intAttrMethod},
+	"required":    {"Required", boolAttrMethod},
+	"readonly":    {"Readonly", boolAttrMethod},
+	"role":        {"Role", stringAttrMethod},
+	"alt":         {"Alt", stringAttrMethod},
+	"target":      {"Target", stringAttrMethod},
+	"name":        {"Name", stringAttrMethod},
+	"value":       {"Value", stringAttrMethod},
+	"for":         {"For", stringAttrMethod},
+	"type":        {"Type", stringAttrMethod},
+	"placeholder": {"Placeholder", stringAttrMethod},
+	"src":         {"Src", stringAttrMethod},
+	"property":    {"Property", stringAttrMethod},
+	"action":      {"Action", stringAttrMethod},
+	"method":      {"Method", stringAttrMethod},
+	"content":     {"Content", stringAttrMethod},
+	"charset":     {"Charset", stringAttrMethod},
+	"disabled":    {"Disabled", boolAttrMethod},
+	"checked":     {"Checked", boolAttrMethod},
+	"integrity":   {"Integrity", stringAttrMethod},
+	"crossorigin": {"CrossOrigin", stringAttrMethod},
+}
@@ -0,0 +1,47 @@
+/*
+html2go converts html markup into Go code building it with htmlgo:
+
+	$ echo '<div class="a"><h1>Hi</h1></div>' | html2go
+	Div(H1("Hi")).Class("a")
+
+It reads the file given as argument, or stdin, and picks the element constructors and typed attribute setters of htmlgo where they exist.
+The code assumes htmlgo is dot imported.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: html2go [file.html]\n\nReads html from file or stdin and prints htmlgo Go code.\n")
+	}
+	flag.Parse()
+
+	var in io.Reader = os.Stdin
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	code, err := convert(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(code)
+}
@@ -0,0 +1,121 @@
+package htmlgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func TestCSPNonce(t *testing.T) {
+	comp := Div(
+		Script("var a = 1;"),
+		Tag("script").Attr("src", "/app.js"),
+		Style(".a{}"),
+		Script("var b = 2;").Attr("nonce", "own"),
+		Div(),
+	)
+
+	ctx := WithCSPNonce(context.TODO(), "abc")
+	expected := `
+<div>
+<script type='text/javascript' nonce='abc'>var a = 1;</script>
+
+<script src='/app.js' nonce='abc'></script>
+
+<style type='text/css' nonce='abc'>.a{}</style>
+
+<script type='text/javascript' nonce='own'>var b = 2;</script>
+
+<div></div>
+</div>
+`
+	diff := testingutils.PrettyJsonDiff(expected, MustString(comp, ctx))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+
+	if strings.Contains(MustString(comp, context.TODO()), "'abc'") {
+		t.Error("nonce rendered without WithCSPNonce")
+	}
+}
+
+func TestCSPHandler(t *testing.T) {
+	h := CSPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Fprint(w, Script("go()"), r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	header := w.Header().Get("Content-Security-Policy")
+	nonce := strings.TrimSuffix(strings.TrimPrefix(header, "script-src 'nonce-"), header[strings.Index(header, "' "):])
+	if len(nonce) == 0 || header != CSPHeader(nonce) {
+		t.Fatalf("unexpected header %q", header)
+	}
+	if !strings.Contains(w.Body.String(), "nonce='"+nonce+"'") {
+		t.Errorf("body does not carry the header nonce: %q", w.Body.String())
+	}
+}
+
+func TestCSPHandlerAssets(t *testing.T) {
+	h := CSPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			RequireAsset(ctx, Asset{Name: "app", Script: "/app.js", Stylesheet: "/app.css"})
+			return nil, nil
+		})
+		Fprint(w, HTML(
+			Head(
+				AssetsOutlet().OnlyStylesheets(),
+				Link("/font.woff2").Rel("preload").Attr("as", "font"),
+				Link("/favicon.ico").Rel("icon"),
+			),
+			Body(app, AssetsOutlet().OnlyScripts()),
+		), r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	header := w.Header().Get("Content-Security-Policy")
+	nonce := strings.TrimSuffix(strings.TrimPrefix(header, "script-src 'nonce-"), header[strings.Index(header, "' "):])
+	body := w.Body.String()
+	for _, el := range []string{"<link href='/app.css'", "<link href='/font.woff2'", "<script src='/app.js'"} {
+		i := strings.Index(body, el)
+		if i < 0 || !strings.Contains(body[i:i+strings.Index(body[i:], ">")], "nonce='"+nonce+"'") {
+			t.Errorf("%s does not carry the header nonce: %q", el, body)
+		}
+	}
+	if strings.Contains(body, "<link href='/favicon.ico' rel='icon' nonce") {
+		t.Errorf("icon link should not carry a nonce: %q", body)
+	}
+}
+
+func TestCSPHeaderAllowsStyleAttributes(t *testing.T) {
+	h := CSPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Fprint(w, Div(Style(".a{color:red}")).Style("display:flex"), r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	directives := map[string]string{}
+	for _, d := range strings.Split(w.Header().Get("Content-Security-Policy"), ";") {
+		fields := strings.Fields(d)
+		if len(fields) > 0 {
+			directives[fields[0]] = strings.Join(fields[1:], " ")
+		}
+	}
+
+	body := w.Body.String()
+	if strings.Contains(body, " style='") && directives["style-src-attr"] != "'unsafe-inline'" {
+		t.Errorf("page uses style attributes the header blocks: %q, %v", body, directives)
+	}
+	if !strings.Contains(body, "' nonce='") || !strings.HasPrefix(directives["style-src"], "'nonce-") {
+		t.Errorf("style element is not allowed by its nonce: %q, %v", body, directives)
+	}
+}
@@ -262,10 +262,10 @@ func Hr() (r *HTMLTagBuilder) {
 
 //     "html": HTMLHtmlElement;
 func HTML(children ...HTMLComponent) (r HTMLComponent) {
-	return HTMLComponents{
-		RawHTML("<!DOCTYPE html>\n"),
+	return CollectHead(
+		doctype{},
 		Tag("html").Children(children...),
-	}
+	)
 }
 
 //     "i": HTMLElement;
@@ -445,10 +445,18 @@ func Samp(children ...HTMLComponent) (r *HTMLTagBuilder) {
 }
 
 //     "script": HTMLScriptElement;
+// script is written as is, except that closing tags and <!-- are escaped so it can not end the element early
 func Script(script string) (r *HTMLTagBuilder) {
 	return Tag("script").
 		Attr("type", "text/javascript").
-		Children(RawHTML(script))
+		Children(RawHTML(escapeRawText("script", script)))
+}
+
+// ScriptJSON embeds v as a json data island, read it back with JSON.parse(el.textContent)
+func ScriptJSON(v interface{}) (r *HTMLTagBuilder) {
+	return Tag("script").
+		Attr("type", "application/json").
+		Children(RawHTML(escapeRawText("script", JSONString(v))))
 }
 
 //     "section": HTMLElement;
@@ -487,10 +495,11 @@ func Strong(text string) (r *HTMLTagBuilder) {
 }
 
 //     "style": HTMLStyleElement;
+// style is written as is, except that closing tags and <!-- are escaped so it can not end the element early
 func Style(style string) (r *HTMLTagBuilder) {
 	return Tag("style").
 		Attr("type", "text/css").
-		Children(RawHTML(style))
+		Children(RawHTML(escapeRawText("style", style)))
 }
 
 //     "sub": HTMLElement;
@@ -0,0 +1,236 @@
+package htmlgo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/*
+SafeURL, SafeJS and SafeCSS mark attribute values that come from trusted code, they are written without the filtering and escaping
+that plain strings get in url, event handler and style attributes:
+
+	A(Text("Run")).Attr("href", SafeURL("javascript:run()"))
+	Button("Save").Attr("onclick", SafeJS("save(this.form)"))
+	Div().Attr("style", SafeCSS("background:url(data:image/png;base64,...)"))
+
+Event handler attributes, the ones starting with "on", only take SafeJS: a plain string there is code that can not be told apart
+from user input, so rendering fails with an error instead of guessing. This breaks code like .Attr("onclick", "save()") that used to work,
+change it to .Attr("onclick", SafeJS("save()")), and build handlers that take user input with JSCall.
+*/
+type SafeURL string
+
+type SafeJS string
+
+type SafeCSS string
+
+/*
+JSCall returns handler code calling fn with args, each encoded as a JavaScript value, so only fn has to be trusted:
+
+	Button("Delete").Attr("onclick", JSCall("confirmDelete", item.Name, item.ID))
+*/
+func JSCall(fn string, args ...interface{}) (r SafeJS) {
+	encoded := make([]string, 0, len(args))
+	for _, a := range args {
+		// json escapes <, >, & and the js line terminators, the attribute escaping takes care of quotes
+		encoded = append(encoded, JSONString(a))
+	}
+	return SafeJS(fn + "(" + strings.Join(encoded, ",") + ")")
+}
+
+type attrContext int
+
+const (
+	attrContextHTML attrContext = iota
+	attrContextURL
+	attrContextJS
+	attrContextCSS
+)
+
+// unsafeValue replaces values that can not be made safe in their context, the same marker html/template uses
+const unsafeValue = "ZgotmplZ"
+
+var urlAttrs = map[string]bool{
+	"action":     true,
+	"background": true,
+	"cite":       true,
+	"codebase":   true,
+	"data":       true,
+	"formaction": true,
+	"href":       true,
+	"icon":       true,
+	"longdesc":   true,
+	"manifest":   true,
+	"poster":     true,
+	"src":        true,
+	"usemap":     true,
+	"xlink:href": true,
+}
+
+func attrContextOf(key string) attrContext {
+	key = strings.ToLower(key)
+	switch {
+	case urlAttrs[key]:
+		return attrContextURL
+	case strings.HasPrefix(key, "on"):
+		return attrContextJS
+	case key == "style":
+		return attrContextCSS
+	}
+	return attrContextHTML
+}
+
+/*
+escapeAttrContext makes an untrusted attribute value safe for the attribute it is written to:
+urls with a scheme other than http, https, mailto or tel are replaced and the rest percent-encoded,
+and unsafe style declarations are replaced. Event handlers are not escaped, attrString refuses untrusted ones.
+*/
+func escapeAttrContext(key string, v string) (r string) {
+	switch attrContextOf(key) {
+	case attrContextURL:
+		return filterURL(v)
+	case attrContextCSS:
+		return filterCSS(v)
+	}
+	return v
+}
+
+func isSafeURL(s string) bool {
+	if i := strings.IndexRune(s, ':'); i >= 0 && !strings.ContainsRune(s[:i], '/') {
+		switch strings.ToLower(s[:i]) {
+		case "http", "https", "mailto", "tel":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func filterURL(s string) (r string) {
+	if !isSafeURL(s) {
+		return "#" + unsafeValue
+	}
+	return normalizeURL(s)
+}
+
+// normalizeURL percent-encodes everything that is not allowed in a url, keeping existing escapes and reserved characters
+func normalizeURL(s string) (r string) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '!', '#', '$', '&', '*', '+', ',', '/', ':', ';', '=', '?', '@', '[', ']', '%':
+			b.WriteByte(c)
+			continue
+		case '-', '.', '_', '~':
+			b.WriteByte(c)
+			continue
+		default:
+			if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+				b.WriteByte(c)
+				continue
+			}
+		}
+		fmt.Fprintf(&b, "%%%02x", c)
+	}
+	return b.String()
+}
+
+var unsafeCSSKeywords = []string{
+	"expression",
+	"javascript:",
+	"vbscript:",
+	"-moz-binding",
+	"behavior",
+	"@import",
+	"</",
+	"<!--",
+	"-->",
+}
+
+// filterCSS checks every declaration of a style attribute, replacing the value of unsafe ones
+func filterCSS(s string) (r string) {
+	decls := splitCSSDeclarations(s)
+	for i, decl := range decls {
+		if len(strings.TrimSpace(decl)) == 0 {
+			continue
+		}
+		colon := strings.IndexByte(decl, ':')
+		if colon < 0 || !isCSSName(strings.TrimSpace(decl[:colon])) {
+			decls[i] = unsafeValue
+			continue
+		}
+		if !isSafeCSSValue(decl[colon+1:]) {
+			decls[i] = decl[:colon+1] + unsafeValue
+		}
+	}
+	return strings.Join(decls, ";")
+}
+
+// splitCSSDeclarations splits a style value at the semicolons that are not inside strings or parentheses, like in url(a;b) or content:";"
+func splitCSSDeclarations(s string) (r []string) {
+	start := 0
+	for {
+		i := indexCSS(s, start, ";")
+		if i < 0 {
+			return append(r, s[start:])
+		}
+		r = append(r, s[start:i])
+		start = i + 1
+	}
+}
+
+func isCSSName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func isSafeCSSValue(v string) bool {
+	if strings.ContainsAny(v, "<>\\{}`") {
+		return false
+	}
+	lower := strings.ToLower(v)
+	for _, k := range unsafeCSSKeywords {
+		if strings.Contains(lower, k) {
+			return false
+		}
+	}
+	for {
+		i := strings.Index(lower, "url(")
+		if i < 0 {
+			return true
+		}
+		lower = lower[i+len("url("):]
+		end := strings.IndexByte(lower, ')')
+		if end < 0 {
+			return false
+		}
+		if !isSafeURL(strings.Trim(strings.TrimSpace(lower[:end]), `'"`)) {
+			return false
+		}
+		lower = lower[end+1:]
+	}
+}
+
+var rawTextCloseTags = map[string]*regexp.Regexp{
+	"script": regexp.MustCompile(`(?i)</(script)`),
+	"style":  regexp.MustCompile(`(?i)</(style)`),
+}
+
+/*
+escapeRawText neutralises the sequences that end a script or style element or switch the parser into its escaped state,
+the backslash keeps the meaning of the text in both JavaScript strings and CSS: </script becomes <\/script and <!-- becomes <\!--
+*/
+func escapeRawText(tag string, text string) (r string) {
+	r = rawTextCloseTags[tag].ReplaceAllString(text, `<\/$1`)
+	r = strings.ReplaceAll(r, "<!--", `<\!--`)
+	return
+}
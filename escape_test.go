@@ -0,0 +1,126 @@
+package htmlgo_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+var attrEscapeCases = []struct {
+	name     string
+	tag      *HTMLTagBuilder
+	expected string
+}{
+	{
+		name:     "safe url is normalized",
+		tag:      A().Href("/search?q=a b&lang=日本"),
+		expected: "\n<a href='/search?q=a%20b&amp;lang=%e6%97%a5%e6%9c%ac'></a>\n",
+	},
+	{
+		name:     "allowed schemes",
+		tag:      A().Href("HTTPS://example.com/").Attr("formaction", "mailto:a@b.c").Attr("src", "tel:+123"),
+		expected: "\n<a href='HTTPS://example.com/' formaction='mailto:a@b.c' src='tel:+123'></a>\n",
+	},
+	{
+		name:     "unsafe schemes",
+		tag:      A().Href("javascript:alert(1)").Attr("action", " java\tscript:alert(1)").Attr("src", "data:text/html,<b>"),
+		expected: "\n<a href='#ZgotmplZ' action='#ZgotmplZ' src='#ZgotmplZ'></a>\n",
+	},
+	{
+		name:     "relative url with colon after slash",
+		tag:      Img("/images/a:b.png"),
+		expected: "\n<img src='/images/a:b.png'>\n",
+	},
+	{
+		name:     "event handler with untrusted args",
+		tag:      Button("Go").Attr("onclick", JSCall("greet", "O'Neil</script>", 1)),
+		expected: "\n<button onclick='greet(\"O&#39;Neil\\u003c/script\\u003e\",1)'>Go</button>\n",
+	},
+	{
+		name:     "style declarations are filtered",
+		tag:      Div().Style("color:red").Style("background: url(javascript:alert(1))").Style("width:expression(alert(1))"),
+		expected: "\n<div style='color:red; background:ZgotmplZ; width:ZgotmplZ;'></div>\n",
+	},
+	{
+		name:     "style with safe url",
+		tag:      Div().Attr("style", "background-image: url('/bg.png'); x{y}: 1"),
+		expected: "\n<div style='background-image: url(&#39;/bg.png&#39;);ZgotmplZ'></div>\n",
+	},
+	{
+		name:     "semicolons inside urls and strings",
+		tag:      Div().Style("background:url('https://cdn/x;v=1.png')").Style(`content:";"`),
+		expected: "\n<div style='background:url(&#39;https://cdn/x;v=1.png&#39;); content:\";\";'></div>\n",
+	},
+	{
+		name:     "semicolon inside an unsafe url",
+		tag:      Div().Style("color:red").Style("background:url(javascript:a;b)"),
+		expected: "\n<div style='color:red; background:ZgotmplZ;'></div>\n",
+	},
+	{
+		name: "trusted values",
+		tag: A().
+			Attr("href", SafeURL("javascript:run()")).
+			Attr("onclick", SafeJS("save(this.form)")).
+			Attr("style", SafeCSS("background:url(data:image/png;base64,AAAA)")),
+		expected: "\n<a href='javascript:run()' onclick='save(this.form)' style='background:url(data:image/png;base64,AAAA)'></a>\n",
+	},
+}
+
+func TestAttrEscaping(t *testing.T) {
+	for _, c := range attrEscapeCases {
+		r := MustString(c.tag, context.TODO())
+		diff := testingutils.PrettyJsonDiff(c.expected, r)
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+}
+
+func TestPlainStringEventHandler(t *testing.T) {
+	// handlers written before SafeJS existed must fail loudly, not turn into a string literal that does nothing
+	_, err := Button("x").Attr("onclick", "go()").MarshalHTML(context.TODO())
+	if err == nil || !strings.Contains(err.Error(), "SafeJS") {
+		t.Errorf("expected an error pointing to SafeJS, got %v", err)
+	}
+
+	r := MustString(Button("x").Attr("onclick", SafeJS("go()")), context.TODO())
+	diff := testingutils.PrettyJsonDiff("\n<button onclick='go()'>x</button>\n", r)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestScriptAndStyleContent(t *testing.T) {
+	payload := `{"name": "</script><script>alert(1)</script>"}`
+	cases := []struct {
+		name     string
+		comp     HTMLComponent
+		expected string
+	}{
+		{
+			name:     "script",
+			comp:     Script(`var data = ` + payload + `; // <!-- </SCRIPT >`),
+			expected: "\n<script type='text/javascript'>var data = {\"name\": \"<\\/script><script>alert(1)<\\/script>\"}; // <\\!-- <\\/SCRIPT ></script>\n",
+		},
+		{
+			name:     "style",
+			comp:     Style(`.a:after { content: "</style><b>" } <!-- -->`),
+			expected: "\n<style type='text/css'>.a:after { content: \"<\\/style><b>\" } <\\!-- --></style>\n",
+		},
+		{
+			name:     "script json",
+			comp:     ScriptJSON(map[string]string{"name": "</script><!--"}).Id("data"),
+			expected: "\n<script type='application/json' id='data'>{\"name\":\"\\u003c/script\\u003e\\u003c!--\"}</script>\n",
+		},
+	}
+	for _, c := range cases {
+		r := MustString(c.comp, context.TODO())
+		diff := testingutils.PrettyJsonDiff(c.expected, r)
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+}
@@ -243,10 +243,12 @@ func ExampleTag_06httphandler() {
 		Name string
 	}
 
+	currentUserKey := NewContextKey[*User]("currentUser")
+
 	userStatus := func() HTMLComponent {
 		return ComponentFunc(func(ctx context.Context) (r []byte, err error) {
 
-			if currentUser, ok := ctx.Value("currentUser").(*User); ok {
+			if currentUser, ok := Use(ctx, currentUserKey); ok {
 				return Div(
 					Text(currentUser.Name),
 				).Class("username").MarshalHTML(ctx)
@@ -286,7 +288,7 @@ func ExampleTag_06httphandler() {
 
 	homeHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := getLoginUserFromCookie(r)
-		ctx := context.WithValue(context.TODO(), "currentUser", user)
+		ctx := Provide(context.TODO(), currentUserKey, user)
 
 		root := Div(
 			Text("This is my home page"),
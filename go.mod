@@ -2,6 +2,9 @@ module github.com/theplant/htmlgo
 
 go 1.18
 
-require github.com/theplant/testingutils v0.0.0-20190603093022-26d8b4d95c61
+require (
+	github.com/theplant/testingutils v0.0.0-20190603093022-26d8b4d95c61
+	golang.org/x/net v0.27.0
+)
 
 require github.com/pmezard/go-difflib v1.0.0 // indirect
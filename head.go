@@ -0,0 +1,303 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+type headContextKey int
+
+const headCollectorKey headContextKey = iota
+
+type headCollector struct {
+	mu      sync.Mutex
+	entries []HTMLComponent
+	assets  []Asset
+	// files written by the asset outlets so far
+	writtenAssets map[string]bool
+	// scope classes of the ScopedStyle stylesheets written so far
+	scopedStyles map[string]bool
+	writer       *headWriter
+	outlets      []*deferredOutlet
+	// headOutlet is set once HeadOutlet is written, a page has only one
+	headOutlet bool
+}
+
+// deferredOutlet is an outlet that is written when the whole page is rendered
+type deferredOutlet struct {
+	marker []byte
+	// ctx the outlet was rendered with, so its content is laid out at its depth
+	ctx    context.Context
+	render func(c *headCollector, ctx context.Context) ([]byte, error)
+}
+
+func headCollectorFrom(ctx context.Context) (c *headCollector, ok bool) {
+	c, ok = ctx.Value(headCollectorKey).(*headCollector)
+	return
+}
+
+// deferOutlet writes a marker for the outlet, everything after it is held back until the page is rendered and the markers are replaced
+func (c *headCollector) deferOutlet(ctx context.Context, w io.Writer, render func(c *headCollector, ctx context.Context) ([]byte, error)) (err error) {
+	c.mu.Lock()
+	o := &deferredOutlet{
+		marker: []byte(fmt.Sprintf("\x00htmlgo-outlet-%p-%d\x00", c, len(c.outlets))),
+		ctx:    ctx,
+		render: render,
+	}
+	c.outlets = append(c.outlets, o)
+	c.writer.hold()
+	c.mu.Unlock()
+
+	_, err = w.Write(o.marker)
+	return
+}
+
+// stage returns a collector for a subtree whose entries, assets, outlets and stylesheets are only kept if commit is called with it
+func (c *headCollector) stage() (r *headCollector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r = &headCollector{
+		writer:       c.writer,
+		headOutlet:   c.headOutlet,
+		scopedStyles: map[string]bool{},
+	}
+	for class := range c.scopedStyles {
+		r.scopedStyles[class] = true
+	}
+	return
+}
+
+func (c *headCollector) commit(staged *headCollector) {
+	staged.mu.Lock()
+	defer staged.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, staged.entries...)
+	c.assets = append(c.assets, staged.assets...)
+	c.outlets = append(c.outlets, staged.outlets...)
+	c.headOutlet = c.headOutlet || staged.headOutlet
+	if c.scopedStyles == nil {
+		c.scopedStyles = map[string]bool{}
+	}
+	for class := range staged.scopedStyles {
+		c.scopedStyles[class] = true
+	}
+}
+
+/*
+AddHead adds comps to the <head> of the page being rendered, where HeadOutlet puts them, so nested components can declare their own
+meta, link or script tags. Tags for the same thing, like two <meta name="description"> or <link rel="canonical">, are written once,
+in the place of the first one added and with the value of the last one, so inner components override the page.
+
+	func ProductPage(ctx context.Context, p *Product) HTMLComponent {
+		SetTitle(ctx, p.Name)
+		AddHead(ctx, Meta().Attr("property", "og:image").Content(p.ImageURL))
+		return Div(...)
+	}
+
+It is called while rendering, like from a ComponentFunc. Outside of HTML or CollectHead it does nothing,
+and comps added from Async content arrive after the head is written and are dropped.
+*/
+func AddHead(ctx context.Context, comps ...HTMLComponent) {
+	c, ok := headCollectorFrom(ctx)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, comp := range comps {
+		if comp != nil {
+			c.entries = append(c.entries, comp)
+		}
+	}
+}
+
+// SetTitle sets the <title> of the page being rendered, the last title set wins, see AddHead.
+func SetTitle(ctx context.Context, title string) {
+	AddHead(ctx, Title(title))
+}
+
+type headOutlet struct{}
+
+/*
+HeadOutlet writes the tags added with AddHead and SetTitle, put it in the Head of your layout:
+
+	HTML(
+		Head(
+			Meta().Charset("utf-8"),
+			HeadOutlet(),
+		),
+		Body(page),
+	)
+
+The tags are only known once the whole page is rendered, so everything after the outlet is held back until then, and a Flush()
+inside the page does not flush. It must be rendered inside HTML or CollectHead.
+*/
+func HeadOutlet() (r HTMLComponent) {
+	return headOutlet{}
+}
+
+func (o headOutlet) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = o.WriteHTML(ctx, buf)
+	r = buf.Bytes()
+	return
+}
+
+func (headOutlet) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	c, ok := headCollectorFrom(ctx)
+	if !ok {
+		return errors.New("htmlgo: HeadOutlet rendered outside of HTML or CollectHead")
+	}
+	c.mu.Lock()
+	if c.headOutlet {
+		c.mu.Unlock()
+		return errors.New("htmlgo: more than one HeadOutlet in a page")
+	}
+	c.headOutlet = true
+	c.mu.Unlock()
+
+	return c.deferOutlet(ctx, w, (*headCollector).renderHead)
+}
+
+type headScope []HTMLComponent
+
+/*
+CollectHead renders children with a head collector in ctx, for AddHead, SetTitle, HeadOutlet, RequireAsset and AssetsOutlet to work within them.
+HTML does this already, use it for layouts that do not start with HTML.
+*/
+func CollectHead(children ...HTMLComponent) (r HTMLComponent) {
+	return headScope(children)
+}
+
+func (s headScope) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = s.WriteHTML(ctx, buf)
+	r = buf.Bytes()
+	return
+}
+
+func (s headScope) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	hw := &headWriter{w: w}
+	c := &headCollector{writer: hw}
+	err = HTMLComponents(s).WriteHTML(context.WithValue(ctx, headCollectorKey, c), hw)
+	if err != nil || hw.held == nil {
+		return
+	}
+
+	out := hw.held.Bytes()
+	for _, o := range c.outlets {
+		var content []byte
+		content, err = o.render(c, o.ctx)
+		if err != nil {
+			return
+		}
+		out = bytes.Replace(out, o.marker, content, 1)
+	}
+	_, err = w.Write(out)
+	return
+}
+
+// renderHead writes the added tags with the duplicates dropped
+func (c *headCollector) renderHead(ctx context.Context) (r []byte, err error) {
+	c.mu.Lock()
+	entries := append([]HTMLComponent(nil), c.entries...)
+	c.mu.Unlock()
+
+	var order []string
+	written := map[string][]byte{}
+	for _, e := range entries {
+		buf := bytes.NewBuffer(nil)
+		err = writeHTML(ctx, buf, e)
+		if err != nil {
+			return
+		}
+		key := headKey(e)
+		if len(key) == 0 {
+			key = "html " + buf.String()
+		}
+		if _, ok := written[key]; !ok {
+			order = append(order, key)
+		}
+		written[key] = buf.Bytes()
+	}
+
+	for _, key := range order {
+		r = append(r, written[key]...)
+	}
+	return
+}
+
+// headKey tells which tags describe the same thing, so only one of them is written
+func headKey(c HTMLComponent) (key string) {
+	b, ok := c.(*HTMLTagBuilder)
+	if !ok || b == nil {
+		return
+	}
+	switch b.tag {
+	case "title", "base":
+		return b.tag
+	case "meta":
+		if _, ok := writtenAttr(b, "charset"); ok {
+			return "meta charset"
+		}
+		for _, k := range []string{"name", "property", "http-equiv", "itemprop"} {
+			if v, ok := writtenAttr(b, k); ok {
+				return "meta " + k + "=" + v
+			}
+		}
+	case "link":
+		rel, _ := writtenAttr(b, "rel")
+		if rel == "canonical" {
+			return "link canonical"
+		}
+		if href, ok := writtenAttr(b, "href"); ok {
+			return "link " + rel + " " + href
+		}
+	case "script":
+		if src, ok := writtenAttr(b, "src"); ok {
+			return "script " + src
+		}
+	}
+	return
+}
+
+// headWriter passes writes through until the outlet is written, and holds back everything after it
+type headWriter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	held *bytes.Buffer
+}
+
+func (hw *headWriter) hold() {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+	if hw.held == nil {
+		hw.held = bytes.NewBuffer(nil)
+	}
+}
+
+func (hw *headWriter) Write(p []byte) (n int, err error) {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+	if hw.held != nil {
+		return hw.held.Write(p)
+	}
+	return hw.w.Write(p)
+}
+
+func (hw *headWriter) flushHTML() (err error) {
+	hw.mu.Lock()
+	defer hw.mu.Unlock()
+	if hw.held != nil {
+		return
+	}
+	if f, ok := hw.w.(htmlFlusher); ok {
+		return f.flushHTML()
+	}
+	return
+}
@@ -0,0 +1,118 @@
+package htmlgo_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func TestHeadOutlet(t *testing.T) {
+	stylesheet := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		AddHead(ctx, Link("/card.css").Rel("stylesheet"))
+		return Div().Class("card").MarshalHTML(ctx)
+	})
+	page := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		SetTitle(ctx, "Products")
+		AddHead(ctx,
+			Meta().Name("description").Content("All products"),
+			Link("/products").Rel("canonical"),
+		)
+		return Components(
+			stylesheet,
+			stylesheet,
+			ComponentFunc(func(ctx context.Context) ([]byte, error) {
+				SetTitle(ctx, "Shoes")
+				AddHead(ctx, Meta().Name("description").Content("All shoes"))
+				return nil, nil
+			}),
+		).MarshalHTML(ctx)
+	})
+
+	comp := HTML(
+		Head(
+			Meta().Charset("utf-8"),
+			HeadOutlet(),
+		),
+		Body(page),
+	)
+
+	expected := `<!DOCTYPE html>
+
+<html>
+<head>
+<meta charset='utf-8'>
+
+<title>Shoes</title>
+
+<meta name='description' content='All shoes'>
+
+<link href='/products' rel='canonical'>
+
+<link href='/card.css' rel='stylesheet'>
+</head>
+
+<body>
+<div class='card'></div>
+
+<div class='card'></div>
+</body>
+</html>
+`
+	diff := testingutils.PrettyJsonDiff(expected, MustString(comp, context.TODO()))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestHeadOutletErrors(t *testing.T) {
+	if _, err := Head(HeadOutlet()).MarshalHTML(context.TODO()); err == nil {
+		t.Error("expected an error for an outlet outside of HTML")
+	}
+	if _, err := HTML(HeadOutlet(), HeadOutlet()).MarshalHTML(context.TODO()); err == nil {
+		t.Error("expected an error for two outlets")
+	}
+
+	// without a collector AddHead does nothing
+	comp := ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		SetTitle(ctx, "ignored")
+		return []byte("fragment"), nil
+	})
+	diff := testingutils.PrettyJsonDiff("fragment", MustString(comp, context.TODO()))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestCollectHeadIndent(t *testing.T) {
+	comp := CollectHead(
+		Tag("html").Children(
+			Head(HeadOutlet()),
+			Body(ComponentFunc(func(ctx context.Context) ([]byte, error) {
+				SetTitle(ctx, "Indented")
+				return P(Text("hi")).MarshalHTML(ctx)
+			})),
+		),
+	)
+
+	var buf bytes.Buffer
+	err := FprintWithOptions(&buf, comp, context.TODO(), RenderOptions{Indent: "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `<html>
+  <head>
+    <title>Indented</title>
+  </head>
+  <body>
+    <p>hi</p>
+  </body>
+</html>
+`
+	diff := testingutils.PrettyJsonDiff(expected, buf.String())
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
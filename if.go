@@ -1,6 +1,9 @@
 package htmlgo
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 type IfBuilder struct {
 	comps []HTMLComponent
@@ -43,6 +46,10 @@ func (b *IfBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
 	return HTMLComponents(b.comps).MarshalHTML(ctx)
 }
 
+func (b *IfBuilder) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	return HTMLComponents(b.comps).WriteHTML(ctx, w)
+}
+
 type IfFuncBuilder struct {
 	f   func() HTMLComponent
 	set bool
@@ -83,3 +90,10 @@ func (b *IfFuncBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
 	}
 	return b.f().MarshalHTML(ctx)
 }
+
+func (b *IfFuncBuilder) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	if b.f == nil {
+		return
+	}
+	return writeHTML(ctx, w, b.f())
+}
@@ -0,0 +1,110 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+type ParallelBuilder struct {
+	children []HTMLComponent
+	limit    int
+}
+
+/*
+Parallel renders its children concurrently and writes them out in their original order.
+Use it for siblings that do their own I/O, like ComponentFunc panels loading data. At most Limit children
+render at a time, GOMAXPROCS by default. If one child fails, the ctx passed to the others is cancelled and its error is returned.
+*/
+func Parallel(children ...HTMLComponent) (r *ParallelBuilder) {
+	return &ParallelBuilder{children: children}
+}
+
+func (b *ParallelBuilder) Limit(n int) (r *ParallelBuilder) {
+	b.limit = n
+	return b
+}
+
+func (b *ParallelBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = b.WriteHTML(ctx, buf)
+	if err != nil {
+		return
+	}
+	r = buf.Bytes()
+	return
+}
+
+func (b *ParallelBuilder) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	if len(b.children) == 0 {
+		return
+	}
+
+	limit := b.limit
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+	if limit > len(b.children) {
+		limit = len(b.children)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		bufs     = make([]bytes.Buffer, len(b.children))
+		errs     = make([]error, len(b.children))
+		done     = make([]chan struct{}, len(b.children))
+		jobs     = make(chan int, len(b.children))
+		firstErr error
+		failOnce sync.Once
+	)
+	for i := range b.children {
+		done[i] = make(chan struct{})
+		jobs <- i
+	}
+	close(jobs)
+
+	for n := 0; n < limit; n++ {
+		go func() {
+			for i := range jobs {
+				errs[i] = b.renderChild(ctx, &bufs[i], i)
+				if errs[i] != nil {
+					failOnce.Do(func() {
+						firstErr = errs[i]
+						cancel()
+					})
+				}
+				close(done[i])
+			}
+		}()
+	}
+
+	for i := range b.children {
+		<-done[i]
+		if errs[i] != nil {
+			// the child that failed first, not a sibling that saw the cancellation
+			return firstErr
+		}
+		_, err = w.Write(bufs[i].Bytes())
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (b *ParallelBuilder) renderChild(ctx context.Context, w io.Writer, i int) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("htmlgo: parallel child %d panicked: %v", i, p)
+		}
+	}()
+	return writeHTML(ctx, w, b.children[i])
+}
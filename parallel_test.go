@@ -0,0 +1,73 @@
+package htmlgo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/theplant/htmlgo"
+)
+
+func TestParallelKeepsOrder(t *testing.T) {
+	var running, maxRunning int32
+	var children []HTMLComponent
+	for i := 0; i < 6; i++ {
+		i := i
+		children = append(children, ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Duration(6-i) * 5 * time.Millisecond)
+			return Li(Text(fmt.Sprint(i))).MarshalHTML(ctx)
+		}))
+	}
+
+	r := MustString(Ul(Parallel(children...).Limit(2)), context.TODO())
+	expected := MustString(Ul(
+		Li(Text("0")), Li(Text("1")), Li(Text("2")),
+		Li(Text("3")), Li(Text("4")), Li(Text("5")),
+	), context.TODO())
+	if r != expected {
+		t.Errorf("expected %q, got %q", expected, r)
+	}
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 children rendering at once, got %d", maxRunning)
+	}
+}
+
+func TestParallelCancelsOnError(t *testing.T) {
+	failure := errors.New("child failed")
+	cancelled := make(chan struct{})
+	comp := Parallel(
+		ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			select {
+			case <-ctx.Done():
+				close(cancelled)
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				return []byte("too slow"), nil
+			}
+		}),
+		ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			return nil, failure
+		}),
+	).Limit(2)
+
+	_, err := comp.MarshalHTML(context.TODO())
+	if err != failure {
+		t.Errorf("expected %v, got %v", failure, err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(500 * time.Millisecond):
+		t.Error("sibling ctx was not cancelled")
+	}
+}
@@ -0,0 +1,155 @@
+package htmlgo
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/*
+Parse turns html markup into a component tree of *HTMLTagBuilder nodes, with attrs, classes, styles and children populated,
+so fragments from static mockups can be changed with Attr, Class or AppendChildren before rendering:
+
+	comps, err := Parse(strings.NewReader(`<div class="card"><h1>Title</h1></div>`))
+	card := comps[0].(*HTMLTagBuilder)
+	card.Class("featured").AppendChildren(P(Text(description)))
+
+Input starting with a doctype or <html> is parsed as a full document, anything else as the content of a <body>.
+Text is kept as Text, comments, and the content of script and style, as RawHTML. Noscript content is parsed as elements.
+
+Parsed input is treated as untrusted: event handler attributes are dropped, and url and style values are filtered when rendered
+like any plain string, so href="javascript:;" becomes #ZgotmplZ. Use ParseWithOptions with Trusted for markup you wrote yourself.
+*/
+func Parse(r io.Reader) (comps HTMLComponents, err error) {
+	return ParseWithOptions(r, ParseOptions{})
+}
+
+type ParseOptions struct {
+	// Trusted keeps url, event handler and style values as SafeURL, SafeJS and SafeCSS, so mockup markup like
+	// <a href="javascript:;" onclick="toggle()"> renders as written. Only use it for markup from your own templates.
+	Trusted bool
+}
+
+// ParseWithOptions parses html markup like Parse, see ParseOptions.
+func ParseWithOptions(r io.Reader, opts ParseOptions) (comps HTMLComponents, err error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+
+	var nodes []*html.Node
+	if isFullDocument(src) {
+		var doc *html.Node
+		doc, err = html.ParseWithOptions(bytes.NewReader(src), html.ParseOptionEnableScripting(false))
+		if err != nil {
+			return
+		}
+		for c := doc.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, c)
+		}
+	} else {
+		nodes, err = html.ParseFragmentWithOptions(bytes.NewReader(src), &html.Node{
+			Type:     html.ElementNode,
+			Data:     "body",
+			DataAtom: atom.Body,
+		}, html.ParseOptionEnableScripting(false))
+		if err != nil {
+			return
+		}
+	}
+
+	for _, n := range nodes {
+		if c := fromNode(n, opts); c != nil {
+			comps = append(comps, c)
+		}
+	}
+	return
+}
+
+func isFullDocument(src []byte) bool {
+	s := strings.ToLower(string(bytes.TrimSpace(src)))
+	return strings.HasPrefix(s, "<!doctype") || strings.HasPrefix(s, "<html")
+}
+
+// rawTextElements hold text that is not escaped, noscript is not one of them as it is parsed with scripting disabled
+var rawTextElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"xmp":      true,
+	"iframe":   true,
+	"noembed":  true,
+	"noframes": true,
+}
+
+func fromNode(n *html.Node, opts ParseOptions) (r HTMLComponent) {
+	switch n.Type {
+	case html.TextNode:
+		if n.Parent != nil && rawTextElements[n.Parent.Data] {
+			return RawHTML(n.Data)
+		}
+		return Text(n.Data)
+	case html.CommentNode:
+		return RawHTML("<!--" + n.Data + "-->")
+	case html.DoctypeNode:
+		if strings.EqualFold(n.Data, "html") {
+			return doctype{}
+		}
+		return RawHTML("<!DOCTYPE " + n.Data + ">\n")
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	b := Tag(n.Data)
+	if isVoidElement(n.Data) {
+		b.OmitEndTag()
+	}
+	for _, a := range n.Attr {
+		key := a.Key
+		if len(a.Namespace) > 0 {
+			key = a.Namespace + ":" + key
+		}
+		if ctx := attrContextOf(key); ctx != attrContextHTML {
+			switch {
+			case opts.Trusted && ctx == attrContextURL:
+				b.Attr(key, SafeURL(a.Val))
+				continue
+			case opts.Trusted && ctx == attrContextJS:
+				b.Attr(key, SafeJS(a.Val))
+				continue
+			case opts.Trusted && ctx == attrContextCSS:
+				b.Attr(key, SafeCSS(a.Val))
+				continue
+			case ctx == attrContextJS:
+				continue
+			}
+		}
+		switch {
+		case key == "class":
+			b.Class(a.Val)
+		case key == "style":
+			for _, s := range splitCSSDeclarations(a.Val) {
+				b.Style(strings.TrimSpace(s))
+			}
+		case len(a.Val) == 0:
+			// empty values are dropped when rendering, so <input disabled> needs a bool
+			b.Attr(key, true)
+		default:
+			b.Attr(key, a.Val)
+		}
+	}
+
+	var children []HTMLComponent
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if cc := fromNode(c, opts); cc != nil {
+			children = append(children, cc)
+		}
+	}
+	if len(children) > 0 {
+		b.Children(children...)
+	}
+	return b
+}
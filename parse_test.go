@@ -0,0 +1,123 @@
+package htmlgo_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func compactString(t *testing.T, comp HTMLComponent) string {
+	buf := bytes.NewBuffer(nil)
+	err := FprintWithOptions(buf, comp, context.TODO(), RenderOptions{Compact: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "fragment",
+			input:    `<div class="card big" id="c1" style="color: red; margin:0"><h1>Title &amp; more</h1><p>Hello <b>world</b></p><!-- note --></div>`,
+			expected: `<div id='c1' class='card big' style='color: red; margin:0;'><h1>Title &amp; more</h1><p>Hello <b>world</b></p><!-- note --></div>`,
+		},
+		{
+			name:     "semicolons in style values",
+			input:    `<div style="background:url(/img;v=2.png);content:';'"></div>`,
+			expected: `<div style='background:url(/img;v=2.png); content:&#39;;&#39;;'></div>`,
+		},
+		{
+			name:     "noscript content",
+			input:    `<noscript><img src="/a.png"><iframe src="https://gtm/ns"></iframe></noscript>`,
+			expected: `<noscript><img src='/a.png'><iframe src='https://gtm/ns'></iframe></noscript>`,
+		},
+		{
+			name:     "void and boolean attributes",
+			input:    `<form><input type="checkbox" name="a" checked><br/><select><option selected value="1">One</select></form>`,
+			expected: `<form><input type='checkbox' name='a' checked><br><select><option selected value='1'>One</option></select></form>`,
+		},
+		{
+			name:     "script content",
+			input:    `<script>if (a < b && c) { run("</div>") }</script>`,
+			expected: `<script>if (a < b && c) { run("</div>") }</script>`,
+		},
+		{
+			name:     "full document",
+			input:    "<!DOCTYPE html><html lang=\"en\"><head><title>T</title></head><body><p>x</body></html>",
+			expected: `<!DOCTYPE html><html lang='en'><head><title>T</title></head><body><p>x</p></body></html>`,
+		},
+	}
+	for _, c := range cases {
+		comps, err := Parse(strings.NewReader(c.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := testingutils.PrettyJsonDiff(c.expected, compactString(t, comps))
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+}
+
+func TestParseThenModify(t *testing.T) {
+	comps, err := Parse(strings.NewReader(`<ul class="nav"><li><a href="/">Home</a></li></ul>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nav := comps[0].(*HTMLTagBuilder)
+	nav.Class("main").Attr("role", "menu").AppendChildren(Li(A(Text("About")).Href("/about")))
+
+	expected := `<ul role='menu' class='nav main'><li><a href='/'>Home</a></li><li><a href='/about'>About</a></li></ul>`
+	diff := testingutils.PrettyJsonDiff(expected, compactString(t, nav))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestParseTrusted(t *testing.T) {
+	input := `<a class="nav-link" href="javascript:;" onclick="toggle()" style="background:url(data:image/png;base64,AA)">Menu</a>`
+	cases := []struct {
+		name     string
+		opts     ParseOptions
+		expected string
+	}{
+		{
+			name:     "untrusted by default",
+			expected: `<a href='#ZgotmplZ' class='nav-link' style='background:ZgotmplZ;'>Menu</a>`,
+		},
+		{
+			name:     "trusted keeps mockup values",
+			opts:     ParseOptions{Trusted: true},
+			expected: `<a href='javascript:;' onclick='toggle()' style='background:url(data:image/png;base64,AA)' class='nav-link'>Menu</a>`,
+		},
+	}
+	for _, c := range cases {
+		comps, err := ParseWithOptions(strings.NewReader(input), c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := testingutils.PrettyJsonDiff(c.expected, compactString(t, comps))
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+
+	comps, _ := ParseWithOptions(strings.NewReader(input), ParseOptions{Trusted: true})
+	link := comps[0].(*HTMLTagBuilder)
+	link.Style("color:red").Style("top:expression(x)")
+
+	expected := `<a href='javascript:;' onclick='toggle()' style='background:url(data:image/png;base64,AA); color:red; top:ZgotmplZ;' class='nav-link'>Menu</a>`
+	diff := testingutils.PrettyJsonDiff(expected, compactString(t, link))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,84 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+/*
+ContextKey is a typed key for values passed down to components in ctx, so they are read back without a type assertion.
+Every key made with NewContextKey is distinct, even with the same name:
+
+	var CurrentUser = NewContextKey[*User]("currentUser")
+
+	ctx = Provide(ctx, CurrentUser, user)
+	...
+	if user, ok := Use(ctx, CurrentUser); ok {
+		...
+	}
+*/
+type ContextKey[T any] struct {
+	name string
+}
+
+func NewContextKey[T any](name string) (r *ContextKey[T]) {
+	return &ContextKey[T]{name: name}
+}
+
+func (k *ContextKey[T]) String() string {
+	return "htmlgo.ContextKey(" + k.name + ")"
+}
+
+// Provide returns a ctx that holds v under key.
+func Provide[T any](ctx context.Context, key *ContextKey[T], v T) context.Context {
+	return context.WithValue(ctx, key, v)
+}
+
+// Use returns the value provided under key, ok is false if there is none.
+func Use[T any](ctx context.Context, key *ContextKey[T]) (v T, ok bool) {
+	v, ok = ctx.Value(key).(T)
+	return
+}
+
+/*
+Provider renders children with value provided under key, so a layout can pass a theme, the user or the locale
+down to the components deep inside it, without changing the ctx of the rest of the page:
+
+	Body(
+		Provider(Theme, "dark", Sidebar()),
+		Main(content),
+	)
+*/
+func Provider[T any](key *ContextKey[T], value T, children ...HTMLComponent) (r HTMLComponent) {
+	return &providerComponents{
+		provide: func(ctx context.Context) context.Context {
+			return Provide(ctx, key, value)
+		},
+		children: children,
+	}
+}
+
+type providerComponents struct {
+	provide  func(ctx context.Context) context.Context
+	children []HTMLComponent
+}
+
+func (p *providerComponents) GetChildren() (r []HTMLComponent) {
+	return append(r, p.children...)
+}
+
+func (p *providerComponents) ReplaceChildren(children []HTMLComponent) {
+	p.children = children
+}
+
+func (p *providerComponents) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = p.WriteHTML(ctx, buf)
+	r = buf.Bytes()
+	return
+}
+
+func (p *providerComponents) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	return HTMLComponents(p.children).WriteHTML(p.provide(ctx), w)
+}
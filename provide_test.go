@@ -0,0 +1,60 @@
+package htmlgo_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+var themeKey = NewContextKey[string]("theme")
+
+func themed() HTMLComponent {
+	return ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		theme, ok := Use(ctx, themeKey)
+		if !ok {
+			theme = "none"
+		}
+		return Span(theme).MarshalHTML(ctx)
+	})
+}
+
+func TestProvider(t *testing.T) {
+	comp := Div(
+		themed(),
+		Provider(themeKey, "dark",
+			themed(),
+			Provider(themeKey, "light", themed()),
+		),
+		themed(),
+	)
+
+	expected := `
+<div>
+<span>none</span>
+
+<span>dark</span>
+
+<span>light</span>
+
+<span>none</span>
+</div>
+`
+	diff := testingutils.PrettyJsonDiff(expected, MustString(comp, context.TODO()))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestContextKey(t *testing.T) {
+	other := NewContextKey[string]("theme")
+	ctx := Provide(context.TODO(), themeKey, "dark")
+
+	if v, ok := Use(ctx, themeKey); !ok || v != "dark" {
+		t.Errorf("unexpected value %q, %v", v, ok)
+	}
+	if v, ok := Use(ctx, other); ok {
+		t.Errorf("keys with the same name should be distinct, got %q", v)
+	}
+}
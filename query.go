@@ -0,0 +1,445 @@
+package htmlgo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+QuerySelector returns the first element under root, root included, matching the css selector, or nil if none does.
+It runs over the builder tree, descending like Walk, so nothing is rendered:
+
+	email, err := QuerySelector(page, "form input[name=email]")
+
+Supported are tag names, *, #id, .class, [attr], [attr=value] with the =, ~=, ^=, $=, *= and |= operators,
+:nth-child(an+b), and the descendant and > child combinators.
+*/
+func QuerySelector(root HTMLComponent, selector string) (r *HTMLTagBuilder, err error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return
+	}
+	for _, n := range queryNodes(root) {
+		if sel.match(n) {
+			return n.b, nil
+		}
+	}
+	return
+}
+
+// QuerySelectorAll returns all elements under root, root included, matching the css selector in document order, see QuerySelector.
+func QuerySelectorAll(root HTMLComponent, selector string) (r []*HTMLTagBuilder, err error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return
+	}
+	for _, n := range queryNodes(root) {
+		if sel.match(n) {
+			r = append(r, n.b)
+		}
+	}
+	return
+}
+
+type queryNode struct {
+	b      *HTMLTagBuilder
+	parent *queryNode
+	// position among the element children of parent, starting from 1
+	index int
+}
+
+// queryNodes lists the elements under root in document order
+func queryNodes(root HTMLComponent) (r []*queryNode) {
+	var index int
+	collectQueryNodes(root, nil, &index, &r)
+	return
+}
+
+func collectQueryNodes(c HTMLComponent, parent *queryNode, index *int, nodes *[]*queryNode) {
+	if c == nil {
+		return
+	}
+	b, ok := c.(*HTMLTagBuilder)
+	if !ok {
+		// containers like HTMLComponents don't make elements, their children are siblings of theirs
+		for _, child := range childrenOf(c) {
+			collectQueryNodes(child, parent, index, nodes)
+		}
+		return
+	}
+	if b == nil {
+		return
+	}
+
+	*index++
+	n := &queryNode{b: b, parent: parent, index: *index}
+	*nodes = append(*nodes, n)
+	var childIndex int
+	for _, child := range b.children {
+		collectQueryNodes(child, n, &childIndex, nodes)
+	}
+}
+
+// selector is a chain of compound selectors, matched from the last one backwards
+type selector []*compoundSelector
+
+type compoundSelector struct {
+	// how the element relates to the one matched by the previous compound: ' ' descendant or '>' child
+	combinator byte
+	tag        string
+	id         string
+	classes    []string
+	attrs      []attrSelector
+	nths       []nthSelector
+}
+
+type attrSelector struct {
+	key   string
+	op    string
+	value string
+}
+
+// nthSelector matches positions a*n+b for any n >= 0
+type nthSelector struct {
+	a, b int
+}
+
+func (sel selector) match(n *queryNode) bool {
+	return sel.matchAt(len(sel)-1, n)
+}
+
+func (sel selector) matchAt(i int, n *queryNode) bool {
+	if !sel[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if sel[i].combinator == '>' {
+		return n.parent != nil && sel.matchAt(i-1, n.parent)
+	}
+	for p := n.parent; p != nil; p = p.parent {
+		if sel.matchAt(i-1, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compoundSelector) match(n *queryNode) bool {
+	if len(c.tag) > 0 && c.tag != "*" && !strings.EqualFold(c.tag, n.b.tag) {
+		return false
+	}
+	if len(c.id) > 0 {
+		if id, ok := writtenAttr(n.b, "id"); !ok || id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := writtenAttr(n.b, "class")
+		names := strings.Fields(class)
+		for _, want := range c.classes {
+			if !containsString(names, want) {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		if !a.match(n.b) {
+			return false
+		}
+	}
+	for _, nth := range c.nths {
+		if !nth.match(n.index) {
+			return false
+		}
+	}
+	return true
+}
+
+// writtenAttr returns an attr as it would be written, ok is false for attrs that are not written
+func writtenAttr(b *HTMLTagBuilder, key string) (val string, ok bool) {
+	v, ok := b.GetAttr(key)
+	if !ok {
+		return
+	}
+	val, ok, _ = attrValueString(v)
+	return
+}
+
+func (a attrSelector) match(b *HTMLTagBuilder) bool {
+	val, ok := writtenAttr(b, a.key)
+	if !ok {
+		return false
+	}
+	switch a.op {
+	case "":
+		return true
+	case "=":
+		return val == a.value
+	case "~=":
+		return containsString(strings.Fields(val), a.value)
+	case "^=":
+		return len(a.value) > 0 && strings.HasPrefix(val, a.value)
+	case "$=":
+		return len(a.value) > 0 && strings.HasSuffix(val, a.value)
+	case "*=":
+		return len(a.value) > 0 && strings.Contains(val, a.value)
+	case "|=":
+		return val == a.value || strings.HasPrefix(val, a.value+"-")
+	}
+	return false
+}
+
+func (nth nthSelector) match(index int) bool {
+	if nth.a == 0 {
+		return index == nth.b
+	}
+	d := index - nth.b
+	return d%nth.a == 0 && d/nth.a >= 0
+}
+
+type selectorParser struct {
+	src string
+	pos int
+}
+
+func parseSelector(src string) (r selector, err error) {
+	p := &selectorParser{src: strings.TrimSpace(src)}
+	var combinator byte
+	for {
+		var c *compoundSelector
+		c, err = p.compound()
+		if err != nil {
+			return
+		}
+		c.combinator = combinator
+		r = append(r, c)
+
+		space := p.skipSpace()
+		if p.eof() {
+			return
+		}
+		switch {
+		case p.peek() == '>':
+			combinator = '>'
+			p.pos++
+			p.skipSpace()
+		case space:
+			combinator = ' '
+		default:
+			err = p.errorf("unexpected %q", p.peek())
+			return
+		}
+	}
+}
+
+func (p *selectorParser) compound() (r *compoundSelector, err error) {
+	r = &compoundSelector{}
+	start := p.pos
+	if !p.eof() && p.peek() == '*' {
+		p.pos++
+		r.tag = "*"
+	} else {
+		r.tag = p.ident()
+	}
+
+	for !p.eof() {
+		switch p.peek() {
+		case '#':
+			p.pos++
+			if r.id = p.ident(); len(r.id) == 0 {
+				err = p.errorf("missing id after #")
+				return
+			}
+		case '.':
+			p.pos++
+			class := p.ident()
+			if len(class) == 0 {
+				err = p.errorf("missing class name after .")
+				return
+			}
+			r.classes = append(r.classes, class)
+		case '[':
+			p.pos++
+			var a attrSelector
+			if a, err = p.attr(); err != nil {
+				return
+			}
+			r.attrs = append(r.attrs, a)
+		case ':':
+			p.pos++
+			var nth nthSelector
+			if nth, err = p.pseudo(); err != nil {
+				return
+			}
+			r.nths = append(r.nths, nth)
+		default:
+			if p.pos == start {
+				err = p.errorf("unexpected %q", p.peek())
+			}
+			return
+		}
+	}
+	if p.pos == start {
+		err = p.errorf("missing selector")
+	}
+	return
+}
+
+func (p *selectorParser) attr() (r attrSelector, err error) {
+	p.skipSpace()
+	if r.key = p.ident(); len(r.key) == 0 {
+		err = p.errorf("missing attribute name")
+		return
+	}
+	p.skipSpace()
+	if p.eof() {
+		err = p.errorf("missing ]")
+		return
+	}
+	if p.peek() != ']' {
+		for _, op := range []string{"=", "~=", "^=", "$=", "*=", "|="} {
+			if strings.HasPrefix(p.src[p.pos:], op) {
+				r.op = op
+				p.pos += len(op)
+				break
+			}
+		}
+		if len(r.op) == 0 {
+			err = p.errorf("unexpected %q in attribute selector", p.peek())
+			return
+		}
+		p.skipSpace()
+		if r.value, err = p.value(); err != nil {
+			return
+		}
+		p.skipSpace()
+	}
+	if p.eof() || p.peek() != ']' {
+		err = p.errorf("missing ]")
+		return
+	}
+	p.pos++
+	return
+}
+
+func (p *selectorParser) value() (r string, err error) {
+	if p.eof() {
+		err = p.errorf("missing attribute value")
+		return
+	}
+	if q := p.peek(); q == '"' || q == '\'' {
+		end := strings.IndexByte(p.src[p.pos+1:], q)
+		if end < 0 {
+			err = p.errorf("unterminated string")
+			return
+		}
+		r = p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return
+	}
+	if r = p.ident(); len(r) == 0 {
+		err = p.errorf("missing attribute value")
+	}
+	return
+}
+
+func (p *selectorParser) pseudo() (r nthSelector, err error) {
+	name := p.ident()
+	if name != "nth-child" {
+		err = p.errorf("unsupported pseudo-class :%s", name)
+		return
+	}
+	if p.eof() || p.peek() != '(' {
+		err = p.errorf("missing ( after :nth-child")
+		return
+	}
+	end := strings.IndexByte(p.src[p.pos:], ')')
+	if end < 0 {
+		err = p.errorf("missing ) after :nth-child")
+		return
+	}
+	arg := p.src[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+	if r, err = parseNth(arg); err != nil {
+		err = p.errorf("%s", err)
+	}
+	return
+}
+
+// parseNth parses the an+b argument of :nth-child, also odd and even
+func parseNth(arg string) (r nthSelector, err error) {
+	s := strings.ToLower(strings.Join(strings.Fields(arg), ""))
+	switch s {
+	case "odd":
+		return nthSelector{2, 1}, nil
+	case "even":
+		return nthSelector{2, 0}, nil
+	}
+
+	n := strings.IndexByte(s, 'n')
+	if n < 0 {
+		r.b, err = strconv.Atoi(s)
+		if err != nil {
+			err = fmt.Errorf("invalid :nth-child argument %q", arg)
+		}
+		return
+	}
+
+	switch a := s[:n]; a {
+	case "", "+":
+		r.a = 1
+	case "-":
+		r.a = -1
+	default:
+		if r.a, err = strconv.Atoi(a); err != nil {
+			err = fmt.Errorf("invalid :nth-child argument %q", arg)
+			return
+		}
+	}
+	if b := s[n+1:]; len(b) > 0 {
+		if b[0] != '+' && b[0] != '-' {
+			err = fmt.Errorf("invalid :nth-child argument %q", arg)
+			return
+		}
+		if r.b, err = strconv.Atoi(b); err != nil {
+			err = fmt.Errorf("invalid :nth-child argument %q", arg)
+		}
+	}
+	return
+}
+
+func (p *selectorParser) ident() (r string) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c >= 0x80 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *selectorParser) skipSpace() (skipped bool) {
+	for !p.eof() && strings.IndexByte(" \t\n\r\f", p.peek()) >= 0 {
+		p.pos++
+		skipped = true
+	}
+	return
+}
+
+func (p *selectorParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *selectorParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *selectorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("htmlgo: invalid selector %q: %s", p.src, fmt.Sprintf(format, args...))
+}
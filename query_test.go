@@ -0,0 +1,102 @@
+package htmlgo_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func queryPage() HTMLComponent {
+	return Components(
+		Form(
+			Div(
+				Input("email").Type("email").Id("email").Class("field wide"),
+			).Class("row"),
+			Div(
+				Input("password").Type("password").Id("password").Class("field"),
+				Input("remember").Type("checkbox").Checked(false).Id("remember"),
+			).Class("row"),
+			If(true, Button("Sign in").Type("submit").Id("submit")),
+		).Id("login").Attr("data-kind", "auth-form"),
+		Ul(
+			Li(Text("1")).Id("li1"),
+			Components(Li(Text("2")).Id("li2"), Li(Text("3")).Id("li3")),
+			Li(Text("4")).Id("li4"),
+			Li(Text("5")).Id("li5"),
+		),
+	)
+}
+
+func TestQuerySelectorAll(t *testing.T) {
+	var cases = []struct {
+		name     string
+		selector string
+		expected string
+	}{
+		{"tag", "input", "email password remember"},
+		{"id", "#password", "password"},
+		{"class", ".field", "email password"},
+		{"classes", "input.field.wide", "email"},
+		{"attr exists", "[data-kind]", "login"},
+		{"attr value", "form input[name=email]", "email"},
+		{"attr quoted value", `input[type="checkbox"]`, "remember"},
+		{"attr false bool is not written", "[checked]", ""},
+		{"attr prefix", "[data-kind^=auth]", "login"},
+		{"attr word", "[class~=wide]", "email"},
+		{"descendant", "form input", "email password remember"},
+		{"child", "form > input", ""},
+		{"child through If", "form > button", "submit"},
+		{"chain", "form > .row > input", "email password remember"},
+		{"nth-child", "ul > li:nth-child(2)", "li2"},
+		{"nth-child odd", "li:nth-child(odd)", "li1 li3 li5"},
+		{"nth-child an+b", "li:nth-child(2n+2)", "li2 li4"},
+		{"nth-child -n+b", "li:nth-child(-n+2)", "li1 li2"},
+		{"nth-child of input", ".row input:nth-child(1)", "email password"},
+		{"universal", "form > *", "div div submit"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			found, err := QuerySelectorAll(queryPage(), c.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, b := range found {
+				id, ok := b.GetAttr("id")
+				if !ok {
+					id = b.TagName()
+				}
+				ids = append(ids, id.(string))
+			}
+			diff := testingutils.PrettyJsonDiff(c.expected, strings.Join(ids, " "))
+			if len(diff) > 0 {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestQuerySelector(t *testing.T) {
+	page := queryPage()
+	b, err := QuerySelector(page, ".row input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := b.GetAttr("id"); id != "email" {
+		t.Errorf("expected the first match, got %v", id)
+	}
+
+	b, err = QuerySelector(page, "table")
+	if err != nil || b != nil {
+		t.Errorf("expected no match, got %v, %v", b, err)
+	}
+
+	for _, sel := range []string{"", "> div", "div >", "[name", "input[name=]", ".", "li:first-child", "li:nth-child(x)", "div,p"} {
+		if _, err = QuerySelector(page, sel); err == nil {
+			t.Errorf("expected an error for %q", sel)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+)
+
+/*
+RenderOptions changes the layout of the html written by HTMLTagBuilder.
+The zero value keeps the default layout, with a newline before every opening tag and after every closing tag.
+*/
+type RenderOptions struct {
+	// Indent puts every element on its own line, indented by Indent once per nesting level.
+	// Elements holding only text stay on one line, and the content of pre, textarea, script and style is written byte-exact.
+	Indent string
+	// Compact writes elements without any whitespace the caller did not write, it takes precedence over Indent.
+	// Use Compact() to lay out only a subtree this way.
+	Compact bool
+}
+
+type renderConfig struct {
+	opts  RenderOptions
+	depth int
+	// raw writes elements without adding any whitespace, for compact output and inside whitespace-sensitive elements
+	raw bool
+}
+
+type renderContextKey int
+
+const renderConfigKey renderContextKey = iota
+
+// WithRenderOptions returns a ctx that makes everything rendered with it use opts.
+func WithRenderOptions(ctx context.Context, opts RenderOptions) context.Context {
+	return context.WithValue(ctx, renderConfigKey, renderConfig{opts: opts, raw: opts.Compact})
+}
+
+func withRenderConfig(ctx context.Context, cfg renderConfig) context.Context {
+	return context.WithValue(ctx, renderConfigKey, cfg)
+}
+
+func renderConfigFrom(ctx context.Context) (cfg renderConfig, ok bool) {
+	cfg, ok = ctx.Value(renderConfigKey).(renderConfig)
+	if ok && !cfg.raw && len(cfg.opts.Indent) == 0 {
+		ok = false
+	}
+	return
+}
+
+/*
+FprintWithOptions renders root into w like Fprint, laid out according to opts:
+
+	FprintWithOptions(w, comp, ctx, RenderOptions{Indent: "  "})
+*/
+func FprintWithOptions(w io.Writer, root HTMLComponent, ctx context.Context, opts RenderOptions) (err error) {
+	return Fprint(w, root, WithRenderOptions(ctx, opts))
+}
+
+var whitespaceSensitiveTags = map[string]bool{
+	"pre":      true,
+	"textarea": true,
+	"script":   true,
+	"style":    true,
+}
+
+func (b *HTMLTagBuilder) writeFormatted(ctx context.Context, w io.Writer, cfg renderConfig, attrStr string) (err error) {
+	if cfg.raw {
+		return b.writeRaw(ctx, w, attrStr)
+	}
+
+	indent := strings.Repeat(cfg.opts.Indent, cfg.depth)
+	_, err = io.WriteString(w, indent+"<"+b.tag+attrStr+">")
+	if err != nil {
+		return
+	}
+	if b.omitEndTag {
+		_, err = io.WriteString(w, "\n")
+		return
+	}
+
+	cs := flattenChildren(b.children)
+	if whitespaceSensitiveTags[b.tag] || onlyText(cs) {
+		rawCtx := withRenderConfig(ctx, renderConfig{raw: true})
+		for _, c := range cs {
+			err = writeHTML(rawCtx, w, c)
+			if err != nil {
+				return
+			}
+		}
+		_, err = io.WriteString(w, "</"+b.tag+">\n")
+		return
+	}
+
+	_, err = io.WriteString(w, "\n")
+	if err != nil {
+		return
+	}
+	childIndent := indent + cfg.opts.Indent
+	childCtx := withRenderConfig(ctx, renderConfig{opts: cfg.opts, depth: cfg.depth + 1})
+	for _, c := range cs {
+		switch v := c.(type) {
+		case RawHTML:
+			t := strings.TrimSpace(string(v))
+			if len(t) == 0 {
+				continue
+			}
+			_, err = io.WriteString(w, childIndent+t+"\n")
+		case compactComponents:
+			// a compact subtree goes on one line of its own
+			_, err = io.WriteString(w, childIndent)
+			if err == nil {
+				err = v.WriteHTML(ctx, w)
+			}
+			if err == nil {
+				_, err = io.WriteString(w, "\n")
+			}
+		case ComponentFunc:
+			err = writeOpaque(childCtx, w, c, childIndent)
+		case HTMLWriter:
+			// builders and the components holding them lay themselves out, and markers have to reach w itself
+			err = v.WriteHTML(childCtx, w)
+		default:
+			err = writeOpaque(childCtx, w, c, childIndent)
+		}
+		if err != nil {
+			return
+		}
+	}
+	_, err = io.WriteString(w, indent+"</"+b.tag+">\n")
+	return
+}
+
+// writeOpaque writes a component that only returns its html, like a ComponentFunc, on lines of its own unless it already laid itself out
+func writeOpaque(ctx context.Context, w io.Writer, c HTMLComponent, indent string) (err error) {
+	buf := bytes.NewBuffer(nil)
+	err = writeHTML(ctx, buf, c)
+	if err != nil {
+		return
+	}
+	out := buf.Bytes()
+	if bytes.HasPrefix(out, []byte(indent+"<")) && bytes.HasSuffix(out, []byte("\n")) {
+		_, err = w.Write(out)
+		return
+	}
+	t := strings.TrimSpace(string(out))
+	if len(t) == 0 {
+		return
+	}
+	_, err = io.WriteString(w, indent+t+"\n")
+	return
+}
+
+func (b *HTMLTagBuilder) writeRaw(ctx context.Context, w io.Writer, attrStr string) (err error) {
+	_, err = io.WriteString(w, "<"+b.tag+attrStr+">")
+	if err != nil || b.omitEndTag {
+		return
+	}
+	for _, c := range b.children {
+		err = writeHTML(ctx, w, c)
+		if err != nil {
+			return
+		}
+	}
+	_, err = io.WriteString(w, "</"+b.tag+">")
+	return
+}
+
+type doctype struct{}
+
+func (d doctype) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = d.WriteHTML(ctx, buf)
+	r = buf.Bytes()
+	return
+}
+
+func (doctype) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	if cfg, ok := renderConfigFrom(ctx); ok && cfg.raw {
+		_, err = io.WriteString(w, "<!DOCTYPE html>")
+		return
+	}
+	_, err = io.WriteString(w, "<!DOCTYPE html>\n")
+	return
+}
+
+type compactComponents []HTMLComponent
+
+/*
+Compact lays out its children without any whitespace the caller did not write, whatever the RenderOptions of the surrounding render.
+Use it for inline formatting contexts, where the default newlines would show up as spaces between elements:
+
+	P(Compact(Text("Read the "), A(Text("docs")).Href("/docs"), Text(".")))
+*/
+func Compact(children ...HTMLComponent) (r HTMLComponent) {
+	return compactComponents(children)
+}
+
+func (cs compactComponents) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	return HTMLComponents(cs).MarshalHTML(withRenderConfig(ctx, renderConfig{raw: true}))
+}
+
+func (cs compactComponents) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	return HTMLComponents(cs).WriteHTML(withRenderConfig(ctx, renderConfig{raw: true}), w)
+}
+
+// flattenChildren expands HTMLComponents and If branches, so their text and elements are laid out as direct children
+func flattenChildren(comps []HTMLComponent) (r []HTMLComponent) {
+	for _, c := range comps {
+		switch v := c.(type) {
+		case nil:
+		case HTMLComponents:
+			r = append(r, flattenChildren(v)...)
+		case *IfBuilder:
+			r = append(r, flattenChildren(v.comps)...)
+		case *IfFuncBuilder:
+			if v.f != nil {
+				r = append(r, flattenChildren([]HTMLComponent{v.f()})...)
+			}
+		default:
+			r = append(r, c)
+		}
+	}
+	return
+}
+
+func onlyText(comps []HTMLComponent) bool {
+	for _, c := range comps {
+		if _, ok := c.(RawHTML); !ok {
+			return false
+		}
+	}
+	return true
+}
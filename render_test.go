@@ -0,0 +1,153 @@
+package htmlgo_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+var renderOptionsCases = []struct {
+	name     string
+	comp     HTMLComponent
+	opts     RenderOptions
+	expected string
+}{
+	{
+		name: "indent full page",
+		comp: HTML(
+			Head(
+				Meta().Charset("utf8"),
+				Title("My test page"),
+			),
+			Body(
+				Div(
+					Text("hello"),
+					Br(),
+					Span("inline"),
+					Components(Text("more"), A().Href("/")),
+				).Class("box"),
+				ComponentFunc(func(ctx context.Context) ([]byte, error) {
+					return Ul(Li(Text("1")), Li(Text("2"))).MarshalHTML(ctx)
+				}),
+			),
+		),
+		opts: RenderOptions{Indent: "  "},
+		expected: `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset='utf8'>
+    <title>My test page</title>
+  </head>
+  <body>
+    <div class='box'>
+      hello
+      <br>
+      <span>inline</span>
+      more
+      <a href='/'></a>
+    </div>
+    <ul>
+      <li>1</li>
+      <li>2</li>
+    </ul>
+  </body>
+</html>
+`,
+	},
+	{
+		name: "indent keeps whitespace sensitive content",
+		comp: Div(
+			Pre("  keep\n    this  "),
+			Textarea("\n line\n"),
+			Tag("pre").Children(Text("a "), Span("b"), Text(" c")),
+			Script("\n\tvar a = 1;\n"),
+			Style("\n.a { color: red; }\n"),
+		),
+		opts: RenderOptions{Indent: "\t"},
+		expected: "<div>\n" +
+			"\t<pre>  keep\n    this  </pre>\n" +
+			"\t<textarea>\n line\n</textarea>\n" +
+			"\t<pre>a <span>b</span> c</pre>\n" +
+			"\t<script type='text/javascript'>\n\tvar a = 1;\n</script>\n" +
+			"\t<style type='text/css'>\n.a { color: red; }\n</style>\n" +
+			"</div>\n",
+	},
+	{
+		name: "compact",
+		comp: HTML(
+			Head(Title("Compact")),
+			Body(
+				P(Text("Read "), Span("the"), Text(" "), A(Text("docs")).Href("/docs")),
+				Br(),
+				Pre(" as is "),
+			),
+		),
+		opts:     RenderOptions{Compact: true, Indent: "  "},
+		expected: "<!DOCTYPE html><html><head><title>Compact</title></head><body><p>Read <span>the</span> <a href='/docs'>docs</a></p><br><pre> as is </pre></body></html>",
+	},
+	{
+		name: "compact subtree in default layout",
+		comp: Div(
+			P(Compact(Text("Read the "), A(Text("docs")).Href("/docs"), Text("."))),
+		),
+		opts:     RenderOptions{},
+		expected: "\n<div>\n<p>Read the <a href='/docs'>docs</a>.</p>\n</div>\n",
+	},
+	{
+		name: "compact subtree in indented layout",
+		comp: Div(
+			P(Compact(Span("a"), Span("b"))),
+			Compact(Span("c"), Span("d")),
+		),
+		opts: RenderOptions{Indent: "  "},
+		expected: `<div>
+  <p>
+    <span>a</span><span>b</span>
+  </p>
+  <span>c</span><span>d</span>
+</div>
+`,
+	},
+	{
+		name: "opaque children get their own lines",
+		comp: Body(
+			ComponentFunc(func(ctx context.Context) ([]byte, error) {
+				return []byte("plain"), nil
+			}),
+			Pre("  keep"),
+			ComponentFunc(func(ctx context.Context) ([]byte, error) {
+				return []byte("<b>bold</b> text"), nil
+			}),
+		),
+		opts: RenderOptions{Indent: "  "},
+		expected: `<body>
+  plain
+  <pre>  keep</pre>
+  <b>bold</b> text
+</body>
+`,
+	},
+	{
+		name:     "zero options keep default layout",
+		comp:     Div(Div().Text("Hello")),
+		opts:     RenderOptions{},
+		expected: "\n<div>\n<div>Hello</div>\n</div>\n",
+	},
+}
+
+func TestFprintWithOptions(t *testing.T) {
+	for _, c := range renderOptionsCases {
+		buf := bytes.NewBuffer(nil)
+		err := FprintWithOptions(buf, c.comp, context.TODO(), c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := testingutils.PrettyJsonDiff(c.expected, buf.String())
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package htmlgo
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+/*
+SanitizePolicy is an allowlist of the elements, attributes and url schemes Sanitize keeps from untrusted html.
+The input is parsed the way a browser would, so unclosed or misnested tags come out balanced.
+Anything not allowed is dropped: disallowed elements are removed but their text is kept, except for elements like script and style
+whose content is dropped too. Event handler attributes are never kept, whatever the policy says.
+*/
+type SanitizePolicy struct {
+	elements    map[string]bool
+	attrs       map[string]map[string]bool
+	forcedAttrs map[string][]*tagAttr
+	urlSchemes  map[string]bool
+}
+
+func NewSanitizePolicy() (r *SanitizePolicy) {
+	return &SanitizePolicy{
+		elements:    map[string]bool{},
+		attrs:       map[string]map[string]bool{},
+		forcedAttrs: map[string][]*tagAttr{},
+		urlSchemes:  map[string]bool{},
+	}
+}
+
+func (p *SanitizePolicy) AllowElements(names ...string) (r *SanitizePolicy) {
+	for _, n := range names {
+		p.elements[strings.ToLower(n)] = true
+	}
+	return p
+}
+
+// AllowAttrs allows attributes on element, use "*" as element to allow them on every allowed element.
+func (p *SanitizePolicy) AllowAttrs(element string, names ...string) (r *SanitizePolicy) {
+	element = strings.ToLower(element)
+	if p.attrs[element] == nil {
+		p.attrs[element] = map[string]bool{}
+	}
+	for _, n := range names {
+		p.attrs[element][strings.ToLower(n)] = true
+	}
+	return p
+}
+
+// ForceAttr sets an attribute on every kept element of that kind, replacing the value from the input, like rel on user links.
+func (p *SanitizePolicy) ForceAttr(element string, key string, value string) (r *SanitizePolicy) {
+	element = strings.ToLower(element)
+	p.forcedAttrs[element] = append(p.forcedAttrs[element], &tagAttr{key, value})
+	return p
+}
+
+// AllowURLSchemes sets the schemes kept in url attributes like href and src, relative urls are always kept.
+func (p *SanitizePolicy) AllowURLSchemes(schemes ...string) (r *SanitizePolicy) {
+	for _, s := range schemes {
+		p.urlSchemes[strings.ToLower(s)] = true
+	}
+	return p
+}
+
+// UGCBasicPolicy keeps the formatting, lists, links, images and tables usually found in user authored rich text.
+func UGCBasicPolicy() (r *SanitizePolicy) {
+	return NewSanitizePolicy().
+		AllowElements(
+			"p", "br", "hr", "div", "span", "blockquote", "pre", "code",
+			"b", "strong", "i", "em", "u", "s", "del", "ins", "sub", "sup", "small", "mark",
+			"h1", "h2", "h3", "h4", "h5", "h6",
+			"ul", "ol", "li", "dl", "dt", "dd",
+			"table", "caption", "thead", "tbody", "tfoot", "tr", "th", "td",
+			"a", "img", "figure", "figcaption",
+		).
+		AllowAttrs("*", "title").
+		AllowAttrs("a", "href").
+		AllowAttrs("img", "src", "alt", "width", "height").
+		AllowAttrs("blockquote", "cite").
+		AllowAttrs("ol", "start").
+		AllowAttrs("th", "colspan", "rowspan", "scope").
+		AllowAttrs("td", "colspan", "rowspan").
+		ForceAttr("a", "rel", "nofollow ugc").
+		AllowURLSchemes("http", "https", "mailto")
+}
+
+// StrictInlinePolicy keeps only inline formatting and links, for short texts like comments or titles.
+func StrictInlinePolicy() (r *SanitizePolicy) {
+	return NewSanitizePolicy().
+		AllowElements("b", "strong", "i", "em", "u", "s", "sub", "sup", "small", "code", "br", "a").
+		AllowAttrs("a", "href").
+		ForceAttr("a", "rel", "nofollow ugc").
+		AllowURLSchemes("http", "https", "mailto")
+}
+
+// elements whose content is dropped together with them
+var sanitizeDropContent = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"template": true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"textarea": true,
+	"title":    true,
+	"svg":      true,
+	"math":     true,
+}
+
+/*
+Sanitize cleans untrusted html with policy, and can be used anywhere a HTMLComponent is accepted:
+
+	Div(Sanitize(post.Body, UGCBasicPolicy())).Class("post-body")
+*/
+func Sanitize(htmlStr string, policy *SanitizePolicy) (r HTMLComponent) {
+	return RawHTML(policy.Sanitize(htmlStr))
+}
+
+func (p *SanitizePolicy) Sanitize(htmlStr string) (r string) {
+	nodes, err := html.ParseFragment(strings.NewReader(htmlStr), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		// only returned for read errors, which a strings.Reader never has
+		return html.EscapeString(htmlStr)
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		p.sanitizeNode(&buf, n)
+	}
+	return buf.String()
+}
+
+func (p *SanitizePolicy) sanitizeNode(buf *bytes.Buffer, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// comments and doctypes
+		return
+	}
+
+	tag := n.Data
+	if sanitizeDropContent[tag] {
+		return
+	}
+	allowed := p.elements[tag] && len(n.Namespace) == 0
+	if allowed {
+		buf.WriteString("<" + tag)
+		for _, at := range p.sanitizeAttrs(tag, n.Attr) {
+			buf.WriteString(" " + at.key + "='" + escapeAttr(at.value.(string)) + "'")
+		}
+		buf.WriteString(">")
+		if isVoidElement(tag) {
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.sanitizeNode(buf, c)
+	}
+	if allowed {
+		buf.WriteString("</" + tag + ">")
+	}
+}
+
+func (p *SanitizePolicy) sanitizeAttrs(tag string, attrs []html.Attribute) (r []*tagAttr) {
+	forced := p.forcedAttrs[tag]
+	for _, a := range attrs {
+		key := strings.ToLower(a.Key)
+		if len(a.Namespace) > 0 || !isValidAttrName(key) || strings.HasPrefix(key, "on") {
+			continue
+		}
+		if !p.attrs[tag][key] && !p.attrs["*"][key] {
+			continue
+		}
+		// forced is shared by every render of the policy, so it is only read
+		if hasAttr(forced, key) || hasAttr(r, key) {
+			continue
+		}
+		switch attrContextOf(key) {
+		case attrContextURL:
+			if !p.isAllowedURL(a.Val) {
+				continue
+			}
+			a.Val = normalizeURL(strings.TrimSpace(a.Val))
+		case attrContextCSS:
+			a.Val = filterCSS(a.Val)
+		}
+		r = append(r, &tagAttr{key, a.Val})
+	}
+	return append(r, forced...)
+}
+
+func (p *SanitizePolicy) isAllowedURL(u string) bool {
+	u = strings.TrimSpace(u)
+	if i := strings.IndexRune(u, ':'); i >= 0 && !strings.ContainsAny(u[:i], "/?#") {
+		return p.urlSchemes[strings.ToLower(u[:i])]
+	}
+	return true
+}
+
+var voidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"param":  true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+func isVoidElement(tag string) bool {
+	return voidElements[tag]
+}
@@ -0,0 +1,97 @@
+package htmlgo_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+var sanitizeCases = []struct {
+	name     string
+	input    string
+	policy   *SanitizePolicy
+	expected string
+}{
+	{
+		name:     "ugc keeps formatting",
+		input:    `<h2>Title</h2><p class="x">Hello <b>bold</b> &amp; <em>em</em><br/>line</p><ul><li>one<li>two</ul>`,
+		policy:   UGCBasicPolicy(),
+		expected: `<h2>Title</h2><p>Hello <b>bold</b> &amp; <em>em</em><br>line</p><ul><li>one</li><li>two</li></ul>`,
+	},
+	{
+		name:     "ugc drops scripts and handlers",
+		input:    `<p onclick="steal()">x<script>alert(1)</script><style>p{}</style><iframe src="//evil"><b>in</b></iframe></p><img src=x onerror=alert(1)>`,
+		policy:   UGCBasicPolicy(),
+		expected: `<p>x</p><img src='x'>`,
+	},
+	{
+		name:     "ugc filters links",
+		input:    `<a href="javascript:alert(1)" rel="me">a</a><a href=" https://example.com/?a=1&b=2 " href="/x" target="_blank">b</a><a href="/rel">c</a>`,
+		policy:   UGCBasicPolicy(),
+		expected: `<a rel='nofollow ugc'>a</a><a href='https://example.com/?a=1&amp;b=2' rel='nofollow ugc'>b</a><a href='/rel' rel='nofollow ugc'>c</a>`,
+	},
+	{
+		name:     "unknown elements keep their text",
+		input:    `<custom-card><marquee>moving</marquee> text</custom-card><!-- comment -->`,
+		policy:   UGCBasicPolicy(),
+		expected: `moving text`,
+	},
+	{
+		name:     "strict inline",
+		input:    `<div><p>Read <strong>this</strong> <a href="mailto:a@b.c" title="t">mail</a></p><img src="/a.png"></div>`,
+		policy:   StrictInlinePolicy(),
+		expected: `Read <strong>this</strong> <a href='mailto:a@b.c' rel='nofollow ugc'>mail</a>`,
+	},
+	{
+		name:     "unbalanced tags are closed",
+		input:    `<b><i>x</b> y</i></em>`,
+		policy:   StrictInlinePolicy(),
+		expected: `<b><i>x</i></b><i> y</i>`,
+	},
+	{
+		name:  "custom policy",
+		input: `<span style="color:red;background:url(javascript:x)" data-id="1" title="&lt;t&gt;">s</span>`,
+		policy: NewSanitizePolicy().
+			AllowElements("span").
+			AllowAttrs("span", "style", "data-id"),
+		expected: `<span style='color:red;background:ZgotmplZ' data-id='1'>s</span>`,
+	},
+}
+
+func TestSanitize(t *testing.T) {
+	for _, c := range sanitizeCases {
+		r := MustString(Sanitize(c.input, c.policy), context.TODO())
+		diff := testingutils.PrettyJsonDiff(c.expected, r)
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+}
+
+func TestSanitizeConcurrentForcedAttrs(t *testing.T) {
+	policy := NewSanitizePolicy().
+		AllowElements("a").
+		AllowAttrs("a", "href", "title").
+		ForceAttr("a", "rel", "nofollow").
+		ForceAttr("a", "target", "_blank").
+		ForceAttr("a", "referrerpolicy", "no-referrer")
+
+	expected := policy.Sanitize(`<a href="/x" title="x">x</a>`)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if r := policy.Sanitize(`<a href="/x" title="x">x</a>`); r != expected {
+					t.Errorf("unexpected %q", r)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
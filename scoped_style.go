@@ -0,0 +1,306 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+)
+
+type ScopedStyleBuilder struct {
+	class string
+	css   string
+}
+
+/*
+ScopedStyle rewrites css so it only applies within the elements given to Apply, which get a generated scope class
+made of name and a hash of css:
+
+	var cardStyle = ScopedStyle("card", `
+		:scope { border: 1px solid #ddd }
+		.title { color: red }
+	`)
+
+	func Card(title string, body ...HTMLComponent) HTMLComponent {
+		return cardStyle.Apply(
+			Div(H2(title).Class("title"), Div(body...)),
+		)
+	}
+
+Every selector is limited to the scope element and what is inside it, so .title becomes ".card-1a2b3c4d .title,.title.card-1a2b3c4d",
+and :scope stands for the scope element itself. Rules in @media, @supports, @container and @layer blocks are rewritten too,
+other at-rules like @keyframes and @font-face are kept as they are.
+*/
+func ScopedStyle(name string, css string) (r *ScopedStyleBuilder) {
+	h := fnv.New32a()
+	h.Write([]byte(css))
+	class := fmt.Sprintf("%s-%08x", name, h.Sum32())
+	return &ScopedStyleBuilder{
+		class: class,
+		css:   scopeCSS(css, "."+class),
+	}
+}
+
+// ScopeClass returns the generated class Apply adds to the elements.
+func (b *ScopedStyleBuilder) ScopeClass() string {
+	return b.class
+}
+
+// CSS returns the rewritten css.
+func (b *ScopedStyleBuilder) CSS() string {
+	return b.css
+}
+
+/*
+Apply adds the scope class to the outermost HTMLTagBuilder of children, looking through HTMLComponents and If,
+and writes the stylesheet in a Style element the first time it is rendered in a page. Within HTML or CollectHead,
+the stylesheet goes to the HeadOutlet when there is one, except in the content of an Async under Stream, which comes after
+the head and gets it inline. Without them it is written before the children every time.
+Elements made when rendering, like by a ComponentFunc, can use ScopeClass.
+*/
+func (b *ScopedStyleBuilder) Apply(children ...HTMLComponent) (r HTMLComponent) {
+	for _, c := range children {
+		addScopeClass(c, b.class)
+	}
+	return &scopedComponents{style: b, children: children}
+}
+
+func addScopeClass(c HTMLComponent, class string) {
+	if b, ok := c.(*HTMLTagBuilder); ok {
+		if b != nil {
+			b.Class(class)
+		}
+		return
+	}
+	for _, child := range childrenOf(c) {
+		addScopeClass(child, class)
+	}
+}
+
+type scopedComponents struct {
+	style    *ScopedStyleBuilder
+	children []HTMLComponent
+}
+
+func (s *scopedComponents) GetChildren() (r []HTMLComponent) {
+	return append(r, s.children...)
+}
+
+func (s *scopedComponents) ReplaceChildren(children []HTMLComponent) {
+	s.children = children
+}
+
+func (s *scopedComponents) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = s.WriteHTML(ctx, buf)
+	r = buf.Bytes()
+	return
+}
+
+func (s *scopedComponents) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	inline := true
+	if c, ok := headCollectorFrom(ctx); ok {
+		c.mu.Lock()
+		written := c.scopedStyles[s.style.class]
+		if c.scopedStyles == nil {
+			c.scopedStyles = map[string]bool{}
+		}
+		c.scopedStyles[s.style.class] = true
+		headOutlet := c.headOutlet
+		c.mu.Unlock()
+
+		if written {
+			inline = false
+		} else if headOutlet {
+			AddHead(ctx, Style(s.style.css))
+			inline = false
+		}
+	}
+	if inline {
+		err = writeHTML(ctx, w, Style(s.style.css))
+		if err != nil {
+			return
+		}
+	}
+	return HTMLComponents(s.children).WriteHTML(ctx, w)
+}
+
+// scopedCSSBlocks are the at-rules holding rules that are rewritten
+var scopedCSSBlocks = map[string]bool{
+	"media":     true,
+	"supports":  true,
+	"container": true,
+	"layer":     true,
+}
+
+func scopeCSS(css string, scope string) string {
+	var out strings.Builder
+	scopeCSSRules(&out, stripCSSComments(css), scope)
+	return out.String()
+}
+
+func scopeCSSRules(out *strings.Builder, src string, scope string) {
+	i := 0
+	for i < len(src) {
+		j := indexCSS(src, i, "{;")
+		if j < 0 {
+			if rest := strings.TrimSpace(src[i:]); len(rest) > 0 {
+				out.WriteString(rest)
+			}
+			return
+		}
+		prelude := strings.TrimSpace(src[i:j])
+		if src[j] == ';' {
+			// statements like @import or @charset
+			if len(prelude) > 0 {
+				out.WriteString(prelude + ";")
+			}
+			i = j + 1
+			continue
+		}
+
+		end := matchingCSSBrace(src, j)
+		body := src[j+1 : end]
+		switch {
+		case strings.HasPrefix(prelude, "@"):
+			name := strings.ToLower(prelude[1:])
+			if k := strings.IndexAny(name, " \t\n\r("); k >= 0 {
+				name = name[:k]
+			}
+			out.WriteString(prelude + "{")
+			if scopedCSSBlocks[name] {
+				scopeCSSRules(out, body, scope)
+			} else {
+				out.WriteString(body)
+			}
+			out.WriteString("}")
+		default:
+			out.WriteString(scopeSelectorList(prelude, scope) + "{" + strings.TrimSpace(body) + "}")
+		}
+		i = end + 1
+	}
+}
+
+func scopeSelectorList(list string, scope string) string {
+	var scoped []string
+	start := 0
+	for {
+		j := indexCSS(list, start, ",")
+		end := j
+		if j < 0 {
+			end = len(list)
+		}
+		if sel := strings.TrimSpace(list[start:end]); len(sel) > 0 {
+			scoped = append(scoped, scopeSelector(sel, scope)...)
+		}
+		if j < 0 {
+			return strings.Join(scoped, ",")
+		}
+		start = j + 1
+	}
+}
+
+// scopeSelector limits sel to the scope element and its descendants
+func scopeSelector(sel string, scope string) (r []string) {
+	if strings.Contains(sel, ":scope") {
+		return []string{strings.ReplaceAll(sel, ":scope", scope)}
+	}
+	if strings.IndexByte(">+~", sel[0]) >= 0 {
+		return []string{scope + " " + sel}
+	}
+
+	// the scope element itself matching the first compound selector, the class goes before any pseudo-class
+	end := indexCSS(sel, 0, " \t\n\r>+~")
+	if end < 0 {
+		end = len(sel)
+	}
+	at := indexCSS(sel[:end], 0, ":")
+	if at < 0 {
+		at = end
+	}
+	return []string{scope + " " + sel, sel[:at] + scope + sel[at:]}
+}
+
+// indexCSS returns the index of the first of chars at or after from, outside of strings, brackets and parentheses
+func indexCSS(s string, from int, chars string) int {
+	depth := 0
+	for i := from; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\'':
+			i = skipCSSString(s, i)
+		case c == '\\':
+			i++
+		case depth == 0 && strings.IndexByte(chars, c) >= 0:
+			return i
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	return -1
+}
+
+// matchingCSSBrace returns the index of the } closing the { at open, or len(s) if it is not closed
+func matchingCSSBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			i = skipCSSString(s, i)
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// skipCSSString returns the index of the quote ending the string starting at i
+func skipCSSString(s string, i int) int {
+	q := s[i]
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case q:
+			return i
+		}
+	}
+	return len(s)
+}
+
+func stripCSSComments(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"' || s[i] == '\'':
+			end := skipCSSString(s, i)
+			if end >= len(s) {
+				end = len(s) - 1
+			}
+			out.WriteString(s[i : end+1])
+			i = end
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return out.String()
+			}
+			i += end + 3
+		default:
+			out.WriteByte(s[i])
+		}
+	}
+	return out.String()
+}
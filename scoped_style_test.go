@@ -0,0 +1,160 @@
+package htmlgo_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func TestScopedStyleCSS(t *testing.T) {
+	var cases = []struct {
+		name     string
+		css      string
+		expected string
+	}{
+		{
+			name:     "class",
+			css:      `.title { color: red }`,
+			expected: `.SCOPE .title,.title.SCOPE{color: red}`,
+		},
+		{
+			name:     "list and combinators",
+			css:      `h1, ul > li a:hover, > p { margin: 0 }`,
+			expected: `.SCOPE h1,h1.SCOPE,.SCOPE ul > li a:hover,ul.SCOPE > li a:hover,.SCOPE > p{margin: 0}`,
+		},
+		{
+			name:     "scope and pseudo elements",
+			css:      `:scope { border: 1px solid } ::before { content: "{x}" } [data-x="a b"]::after { content: '' }`,
+			expected: `.SCOPE{border: 1px solid}.SCOPE ::before,.SCOPE::before{content: "{x}"}.SCOPE [data-x="a b"]::after,[data-x="a b"].SCOPE::after{content: ''}`,
+		},
+		{
+			name:     "at-rules",
+			css:      "/* card */ @import url(a.css); @media (max-width: 600px) { .a { top: 0 } } @keyframes spin { from { top: 0 } }",
+			expected: `@import url(a.css);@media (max-width: 600px){.SCOPE .a,.a.SCOPE{top: 0}}@keyframes spin{ from { top: 0 } }`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := ScopedStyle("card", c.css)
+			if !strings.HasPrefix(s.ScopeClass(), "card-") {
+				t.Errorf("unexpected scope class %q", s.ScopeClass())
+			}
+			expected := strings.ReplaceAll(c.expected, "SCOPE", s.ScopeClass())
+			diff := testingutils.PrettyJsonDiff(expected, s.CSS())
+			if len(diff) > 0 {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestScopedStyleApply(t *testing.T) {
+	cardStyle := ScopedStyle("card", `.title{color:red}`)
+	card := func(title string) HTMLComponent {
+		return cardStyle.Apply(Components(
+			Div(H2(title).Class("title")).Class("card"),
+			If(true, P(Text("footer"))),
+		))
+	}
+	scope := cardStyle.ScopeClass()
+
+	var cases = []struct {
+		name     string
+		comp     HTMLComponent
+		expected string
+	}{
+		{
+			name: "once per page in the head",
+			comp: HTML(Head(HeadOutlet()), Body(card("a"), card("b"))),
+			expected: `<!DOCTYPE html>
+
+<html>
+<head>
+<style type='text/css'>.SCOPE .title,.title.SCOPE{color:red}</style>
+</head>
+
+<body>
+<div class='card SCOPE'>
+<h2 class='title'>a</h2>
+</div>
+
+<p class='SCOPE'>footer</p>
+
+<div class='card SCOPE'>
+<h2 class='title'>b</h2>
+</div>
+
+<p class='SCOPE'>footer</p>
+</body>
+</html>
+`,
+		},
+		{
+			name: "once per page inline",
+			comp: CollectHead(card("a"), card("b")),
+			expected: `
+<style type='text/css'>.SCOPE .title,.title.SCOPE{color:red}</style>
+
+<div class='card SCOPE'>
+<h2 class='title'>a</h2>
+</div>
+
+<p class='SCOPE'>footer</p>
+
+<div class='card SCOPE'>
+<h2 class='title'>b</h2>
+</div>
+
+<p class='SCOPE'>footer</p>
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expected := strings.ReplaceAll(c.expected, "SCOPE", scope)
+			diff := testingutils.PrettyJsonDiff(expected, MustString(c.comp, context.TODO()))
+			if len(diff) > 0 {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestScopedStyleInStreamedAsync(t *testing.T) {
+	card := ScopedStyle("card", `.title{color:red}`)
+	panel := ScopedStyle("panel", `.body{margin:0}`)
+	page := HTML(
+		Head(HeadOutlet()),
+		Body(
+			card.Apply(Div(Text("shell"))),
+			Async(Text("loading"), func(ctx context.Context) HTMLComponent {
+				return Components(
+					card.Apply(Div(Text("card"))),
+					panel.Apply(Div(Text("a"))),
+					panel.Apply(Div(Text("b"))),
+				)
+			}),
+		),
+	)
+
+	buf := bytes.NewBuffer(nil)
+	err := Stream(buf, page, context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	head := out[:strings.Index(out, "</head>")]
+	content := out[strings.Index(out, "<template"):]
+	if !strings.Contains(head, "{color:red}") || strings.Contains(content, "{color:red}") {
+		t.Errorf("stylesheet of the shell should be in the head only: %s", out)
+	}
+	if strings.Count(content, "{margin:0}") != 1 || strings.Contains(head, "{margin:0}") {
+		t.Errorf("stylesheet of the async content should be inline once: %s", out)
+	}
+}
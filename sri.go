@@ -0,0 +1,90 @@
+package htmlgo
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+/*
+SRIAssets builds script and link elements for files in a fs.FS, with integrity and crossorigin attributes computed from the file content.
+Hashes are computed once per file and cached, so an embedded dist directory can be used directly:
+
+	//go:embed dist
+	var dist embed.FS
+
+	sub, _ := fs.Sub(dist, "dist")
+	assets := NewSRIAssets(sub, "/assets/")
+
+	Head(
+		assets.Link("app.css").Rel("stylesheet"),
+		assets.Script("app.js").Attr("defer", true),
+	)
+*/
+type SRIAssets struct {
+	fsys        fs.FS
+	urlPrefix   string
+	crossOrigin string
+	hashes      sync.Map
+}
+
+// NewSRIAssets serves files of fsys under urlPrefix, the file "app.js" gets the url urlPrefix+"app.js".
+func NewSRIAssets(fsys fs.FS, urlPrefix string) (r *SRIAssets) {
+	return &SRIAssets{
+		fsys:        fsys,
+		urlPrefix:   urlPrefix,
+		crossOrigin: "anonymous",
+	}
+}
+
+// CrossOrigin sets the crossorigin attribute of the elements, "anonymous" by default.
+func (a *SRIAssets) CrossOrigin(v string) (r *SRIAssets) {
+	a.crossOrigin = v
+	return a
+}
+
+// Integrity returns the sha384 integrity value of the file name in the assets fs.
+func (a *SRIAssets) Integrity(name string) (r string, err error) {
+	if h, ok := a.hashes.Load(name); ok {
+		return h.(string), nil
+	}
+
+	f, err := a.fsys.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	sum := sha512.New384()
+	if _, err = io.Copy(sum, f); err != nil {
+		return
+	}
+	r = "sha384-" + base64.StdEncoding.EncodeToString(sum.Sum(nil))
+	a.hashes.Store(name, r)
+	return
+}
+
+func (a *SRIAssets) mustIntegrity(name string) (r string) {
+	r, err := a.Integrity(name)
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// Script returns a script element loading the file name, it panics if the file can not be read.
+func (a *SRIAssets) Script(name string) (r *HTMLTagBuilder) {
+	return Tag("script").
+		Src(a.urlPrefix + name).
+		Integrity(a.mustIntegrity(name)).
+		CrossOrigin(a.crossOrigin)
+}
+
+// Link returns a link element for the file name, set its Rel, it panics if the file can not be read.
+func (a *SRIAssets) Link(name string) (r *HTMLTagBuilder) {
+	return Link(a.urlPrefix + name).
+		Integrity(a.mustIntegrity(name)).
+		CrossOrigin(a.crossOrigin)
+}
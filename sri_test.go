@@ -0,0 +1,54 @@
+package htmlgo_test
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+func TestSRIAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":  {Data: []byte("console.log(1)")},
+		"app.css": {Data: []byte("body{margin:0}")},
+	}
+	hash := func(s string) string {
+		sum := sha512.Sum384([]byte(s))
+		return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	assets := NewSRIAssets(fsys, "/assets/")
+	comp := Head(
+		assets.Link("app.css").Rel("stylesheet"),
+		assets.Script("app.js").Attr("defer", true),
+	)
+	expected := `
+<head>
+<link href='/assets/app.css' integrity='` + hash("body{margin:0}") + `' crossorigin='anonymous' rel='stylesheet'>
+
+<script src='/assets/app.js' integrity='` + hash("console.log(1)") + `' crossorigin='anonymous' defer></script>
+</head>
+`
+	diff := testingutils.PrettyJsonDiff(expected, MustString(comp, context.TODO()))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+
+	// cached per file, changing the fs afterwards does not change the hash
+	fsys["app.js"].Data = []byte("changed")
+	integrity, err := assets.Integrity("app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if integrity != hash("console.log(1)") {
+		t.Errorf("hash was not cached: %s", integrity)
+	}
+
+	if _, err = assets.Integrity("missing.js"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -0,0 +1,292 @@
+package htmlgo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+type flushMarker struct{}
+
+/*
+Flush marks a point in the tree where Stream sends everything rendered so far to the client.
+It renders nothing, and is ignored by MarshalHTML, Fprint, or anywhere below a component that only implements MarshalHTML.
+*/
+func Flush() (r HTMLComponent) {
+	return flushMarker{}
+}
+
+func (flushMarker) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	return
+}
+
+func (flushMarker) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	if f, ok := w.(htmlFlusher); ok {
+		return f.flushHTML()
+	}
+	return
+}
+
+type htmlFlusher interface {
+	flushHTML() error
+}
+
+// streamWriter holds everything written since the last flush, however large, so a failed render sends none of it.
+type streamWriter struct {
+	bytes.Buffer
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func newStreamWriter(w io.Writer) (r *streamWriter) {
+	r = &streamWriter{w: w}
+	r.flusher, _ = w.(http.Flusher)
+	return
+}
+
+func (sw *streamWriter) flushHTML() (err error) {
+	_, err = sw.Buffer.WriteTo(sw.w)
+	if err != nil {
+		return
+	}
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+	return
+}
+
+/*
+Stream renders root into w progressively. Output is buffered and sent at every Flush() marker, and once more at the end,
+calling http.Flusher.Flush when w supports it, so the head and page shell can reach the browser before slow components finish.
+If rendering fails, output after the last Flush marker is discarded, it is held in memory until the next flush however large it gets.
+*/
+func Stream(w io.Writer, root HTMLComponent, ctx context.Context) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reg := &asyncRegistry{results: make(chan asyncResult)}
+	ctx = context.WithValue(ctx, asyncRegistryKey, reg)
+
+	sw := newStreamWriter(w)
+	err = writeHTML(ctx, sw, root)
+	if err != nil {
+		return
+	}
+	err = sw.flushHTML()
+	if err != nil {
+		return
+	}
+
+	// an Async inside another one can finish first, it waits until the template holding its placeholder is written
+	written := map[string]bool{}
+	held := map[string][]asyncResult{}
+	var writeResult func(res asyncResult) error
+	writeResult = func(res asyncResult) (err error) {
+		err = HTMLComponents{
+			Template(RawHTML(res.body)).Id(res.id + "-content"),
+			Script(fmt.Sprintf(asyncSwapScript, res.id+"-content", res.id)),
+		}.WriteHTML(ctx, sw)
+		if err != nil {
+			return
+		}
+		err = sw.flushHTML()
+		if err != nil {
+			return
+		}
+		written[res.id] = true
+		children := held[res.id]
+		delete(held, res.id)
+		for _, child := range children {
+			err = writeResult(child)
+			if err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	for {
+		var res asyncResult
+		var ok bool
+		res, ok, err = reg.next(ctx)
+		if !ok || err != nil {
+			return
+		}
+		if res.err != nil {
+			return res.err
+		}
+		if len(res.parent) > 0 && !written[res.parent] {
+			held[res.parent] = append(held[res.parent], res)
+			continue
+		}
+		err = writeResult(res)
+		if err != nil {
+			return
+		}
+	}
+}
+
+const asyncSwapScript = `(function(){var t=document.getElementById("%s"),p=document.getElementById("%s");p.replaceWith(t.content);t.remove()})()`
+
+type asyncContextKey int
+
+const (
+	asyncRegistryKey asyncContextKey = iota
+	// asyncParentKey holds the id of the Async whose content is being rendered
+	asyncParentKey
+	// asyncStageKey holds the asyncStage of the ErrorBoundary being rendered
+	asyncStageKey
+)
+
+type asyncResult struct {
+	id string
+	// parent is the id of the Async this one is nested in, if any
+	parent string
+	body   []byte
+	err    error
+}
+
+type asyncRegistry struct {
+	mu      sync.Mutex
+	seq     int
+	pending int
+	results chan asyncResult
+}
+
+func (reg *asyncRegistry) start(ctx context.Context, f func(ctx context.Context) HTMLComponent) (id string) {
+	reg.mu.Lock()
+	reg.seq++
+	id = fmt.Sprintf("htmlgo-async-%d", reg.seq)
+	reg.mu.Unlock()
+
+	if stage, ok := ctx.Value(asyncStageKey).(*asyncStage); ok && stage != nil {
+		stage.add(func() {
+			reg.run(ctx, id, f)
+		})
+		return
+	}
+	reg.run(ctx, id, f)
+	return
+}
+
+func (reg *asyncRegistry) run(ctx context.Context, id string, f func(ctx context.Context) HTMLComponent) {
+	reg.mu.Lock()
+	reg.pending++
+	reg.mu.Unlock()
+
+	parent, _ := ctx.Value(asyncParentKey).(string)
+	ctx = context.WithValue(ctx, asyncStageKey, (*asyncStage)(nil))
+	go func() {
+		res := asyncResult{id: id, parent: parent}
+		defer func() {
+			if p := recover(); p != nil {
+				res.err = fmt.Errorf("htmlgo: async component %s panicked: %v", id, p)
+			}
+			select {
+			case reg.results <- res:
+			case <-ctx.Done():
+			}
+		}()
+
+		ctx := context.WithValue(ctx, asyncParentKey, id)
+		if c, ok := headCollectorFrom(ctx); ok {
+			// the head is written before the content arrives, so its ScopedStyle stylesheets are written inline
+			staged := c.stage()
+			staged.headOutlet = false
+			ctx = context.WithValue(ctx, headCollectorKey, staged)
+		}
+		buf := bytes.NewBuffer(nil)
+		res.err = writeHTML(ctx, buf, f(ctx))
+		res.body = buf.Bytes()
+	}()
+}
+
+// asyncStage holds the Async started inside an ErrorBoundary, they only run once its child rendered without error
+type asyncStage struct {
+	mu     sync.Mutex
+	parent *asyncStage
+	starts []func()
+}
+
+func (s *asyncStage) add(starts ...func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.starts = append(s.starts, starts...)
+}
+
+// commit runs the staged Async, or hands them to the boundary around this one
+func (s *asyncStage) commit() {
+	s.mu.Lock()
+	starts := s.starts
+	s.starts = nil
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.add(starts...)
+		return
+	}
+	for _, start := range starts {
+		start()
+	}
+}
+
+func (reg *asyncRegistry) next(ctx context.Context) (res asyncResult, ok bool, err error) {
+	reg.mu.Lock()
+	if reg.pending == 0 {
+		reg.mu.Unlock()
+		return
+	}
+	reg.pending--
+	reg.mu.Unlock()
+
+	select {
+	case res = <-reg.results:
+		ok = true
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return
+}
+
+type AsyncBuilder struct {
+	fallback HTMLComponent
+	f        func(ctx context.Context) HTMLComponent
+}
+
+/*
+Async renders fallback in place and runs f concurrently. When rendered by Stream, the component returned by f is appended
+at the end of the response inside a <template>, together with a small inline script that swaps it in for the fallback,
+so several slow panels can load in parallel and show up in whatever order they finish.
+An Async nested in the content of another one is written after the content holding its fallback.
+Outside Stream there is nothing to append to, so f is called and its result rendered in place instead of the fallback.
+*/
+func Async(fallback HTMLComponent, f func(ctx context.Context) HTMLComponent) (r *AsyncBuilder) {
+	return &AsyncBuilder{fallback: fallback, f: f}
+}
+
+func (b *AsyncBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
+	buf := bytes.NewBuffer(nil)
+	err = b.WriteHTML(ctx, buf)
+	if err != nil {
+		return
+	}
+	r = buf.Bytes()
+	return
+}
+
+func (b *AsyncBuilder) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	reg, _ := ctx.Value(asyncRegistryKey).(*asyncRegistry)
+	if reg == nil {
+		return writeHTML(ctx, w, b.f(ctx))
+	}
+
+	id := reg.start(ctx, b.f)
+	return Tag("htmlgo-async").
+		Id(id).
+		Style("display:contents").
+		Children(b.fallback).
+		WriteHTML(ctx, w)
+}
@@ -0,0 +1,211 @@
+package htmlgo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+type chunkRecorder struct {
+	buf    bytes.Buffer
+	chunks []string
+}
+
+func (c *chunkRecorder) Write(p []byte) (int, error) {
+	return c.buf.Write(p)
+}
+
+func (c *chunkRecorder) Flush() {
+	c.chunks = append(c.chunks, c.buf.String())
+	c.buf.Reset()
+}
+
+func TestStream(t *testing.T) {
+	w := &chunkRecorder{}
+	comp := HTMLComponents{
+		Head(Title("Streaming")),
+		Flush(),
+		Body(
+			Div(Text("shell")),
+			Flush(),
+			Div(Text("slow")),
+		),
+	}
+	err := Stream(w, comp, context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"\n<head>\n<title>Streaming</title>\n</head>\n",
+		"\n<body>\n<div>shell</div>\n",
+		"\n<div>slow</div>\n</body>\n",
+	}
+	diff := testingutils.PrettyJsonDiff(expected, w.chunks)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+
+	withFlush := MustString(Div(Text("a"), Flush(), Text("b")), context.TODO())
+	if withFlush != "\n<div>ab</div>\n" {
+		t.Errorf("Flush should render nothing outside Stream, got %q", withFlush)
+	}
+}
+
+func TestStreamIndented(t *testing.T) {
+	w := &chunkRecorder{}
+	comp := Div(Div(Span("a"), Flush(), Span("b")))
+	err := Stream(w, comp, WithRenderOptions(context.TODO(), RenderOptions{Indent: "  "}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"<div>\n  <div>\n    <span>a</span>\n",
+		"    <span>b</span>\n  </div>\n</div>\n",
+	}
+	diff := testingutils.PrettyJsonDiff(expected, w.chunks)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestStreamErrorDiscardsUnflushed(t *testing.T) {
+	w := &chunkRecorder{}
+	comp := HTMLComponents{
+		Div(Text("sent")),
+		Flush(),
+		Div(Text("discarded")),
+		ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			return nil, errors.New("boom")
+		}),
+	}
+	err := Stream(w, comp, context.TODO())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	diff := testingutils.PrettyJsonDiff([]string{"\n<div>sent</div>\n"}, w.chunks)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+	if w.buf.Len() > 0 {
+		t.Errorf("unflushed output was written: %q", w.buf.String())
+	}
+}
+
+func TestStreamErrorDiscardsLargeUnflushed(t *testing.T) {
+	w := &chunkRecorder{}
+	comp := HTMLComponents{
+		Div(Text("sent")),
+		Flush(),
+		Div(Text(strings.Repeat("discarded ", 1000))),
+		ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			return nil, errors.New("boom")
+		}),
+	}
+	err := Stream(w, comp, context.TODO())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if w.buf.Len() > 0 || len(w.chunks) != 1 {
+		t.Errorf("unflushed output was written: %d bytes, %d chunks", w.buf.Len(), len(w.chunks))
+	}
+}
+
+func TestStreamAsync(t *testing.T) {
+	w := &chunkRecorder{}
+	slowDone := make(chan struct{})
+	comp := Body(
+		Async(Text("loading slow"), func(ctx context.Context) HTMLComponent {
+			<-slowDone
+			return Div(Text("slow panel"))
+		}),
+		Async(Text("loading fast"), func(ctx context.Context) HTMLComponent {
+			defer close(slowDone)
+			return Div(Text("fast panel"))
+		}),
+	)
+	err := Stream(w, comp, context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"\n<body>\n<htmlgo-async id='htmlgo-async-1' style='display:contents;'>loading slow</htmlgo-async>\n" +
+			"\n<htmlgo-async id='htmlgo-async-2' style='display:contents;'>loading fast</htmlgo-async>\n</body>\n",
+		"\n<template id='htmlgo-async-2-content'>\n<div>fast panel</div>\n</template>\n" +
+			"\n<script type='text/javascript'>(function(){var t=document.getElementById(\"htmlgo-async-2-content\"),p=document.getElementById(\"htmlgo-async-2\");p.replaceWith(t.content);t.remove()})()</script>\n",
+		"\n<template id='htmlgo-async-1-content'>\n<div>slow panel</div>\n</template>\n" +
+			"\n<script type='text/javascript'>(function(){var t=document.getElementById(\"htmlgo-async-1-content\"),p=document.getElementById(\"htmlgo-async-1\");p.replaceWith(t.content);t.remove()})()</script>\n",
+	}
+	diff := testingutils.PrettyJsonDiff(expected, w.chunks)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestStreamNestedAsync(t *testing.T) {
+	w := &chunkRecorder{}
+	innerDone := make(chan struct{})
+	comp := Body(
+		Async(Text("loading outer"), func(ctx context.Context) HTMLComponent {
+			return Div(
+				Async(Text("loading inner"), func(ctx context.Context) HTMLComponent {
+					defer close(innerDone)
+					return Text("inner panel")
+				}),
+				ComponentFunc(func(ctx context.Context) ([]byte, error) {
+					// let the inner result reach Stream before the outer one
+					<-innerDone
+					time.Sleep(20 * time.Millisecond)
+					return []byte("outer panel"), nil
+				}),
+			)
+		}),
+	)
+	err := Stream(w, comp, context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := strings.Join(w.chunks, "")
+	outer := strings.Index(out, "<template id='htmlgo-async-1-content'>")
+	placeholder := strings.Index(out, "<htmlgo-async id='htmlgo-async-2'")
+	inner := strings.Index(out, "<template id='htmlgo-async-2-content'>")
+	if outer < 0 || placeholder < outer || inner < placeholder {
+		t.Errorf("inner content must follow the outer template holding its placeholder:\n%s", out)
+	}
+}
+
+func TestAsyncOutsideStream(t *testing.T) {
+	comp := Div(
+		Async(Text("loading"), func(ctx context.Context) HTMLComponent {
+			return Text("loaded")
+		}),
+	)
+	r := MustString(comp, context.TODO())
+	if r != "\n<div>loaded</div>\n" {
+		t.Errorf("unexpected output %q", r)
+	}
+}
+
+func TestStreamAsyncError(t *testing.T) {
+	w := &chunkRecorder{}
+	comp := Div(
+		Async(Text("loading"), func(ctx context.Context) HTMLComponent {
+			return ComponentFunc(func(ctx context.Context) ([]byte, error) {
+				return nil, errors.New("panel failed")
+			})
+		}),
+	)
+	err := Stream(w, comp, context.TODO())
+	if err == nil || err.Error() != "panel failed" {
+		t.Errorf("expected panel error, got %v", err)
+	}
+}
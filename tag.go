@@ -5,9 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"html"
+	"io"
 	"strings"
 	"sync"
+	"unicode/utf8"
 )
 
 type tagAttr struct {
@@ -81,6 +82,26 @@ func (b *HTMLTagBuilder) Attr(vs ...interface{}) (r *HTMLTagBuilder) {
 	return b
 }
 
+// RemoveAttr removes attributes, removing "class" or "style" also drops what Class or Style added.
+func (b *HTMLTagBuilder) RemoveAttr(keys ...string) (r *HTMLTagBuilder) {
+	for _, k := range keys {
+		switch k {
+		case "class":
+			b.classNames = nil
+		case "style":
+			b.styles = nil
+		}
+		var attrs []*tagAttr
+		for _, at := range b.attrs {
+			if at.key != k {
+				attrs = append(attrs, at)
+			}
+		}
+		b.attrs = attrs
+	}
+	return b
+}
+
 func (b *HTMLTagBuilder) AttrIf(key, value interface{}, add bool) (r *HTMLTagBuilder) {
 	if !add {
 		return b
@@ -95,16 +116,21 @@ func (b *HTMLTagBuilder) Class(names ...string) (r *HTMLTagBuilder) {
 }
 
 func (b *HTMLTagBuilder) addClass(names ...string) (r *HTMLTagBuilder) {
+	b.classNames = append(b.classNames, splitClassNames(names...)...)
+	return b
+}
+
+func splitClassNames(names ...string) (r []string) {
 	for _, n := range names {
 		ins := strings.Split(n, " ")
 		for _, in := range ins {
 			tin := strings.TrimSpace(in)
 			if len(tin) > 0 {
-				b.classNames = append(b.classNames, tin)
+				r = append(r, tin)
 			}
 		}
 	}
-	return b
+	return
 }
 
 func (b *HTMLTagBuilder) ClassIf(name string, add bool) (r *HTMLTagBuilder) {
@@ -115,6 +141,49 @@ func (b *HTMLTagBuilder) ClassIf(name string, add bool) (r *HTMLTagBuilder) {
 	return b
 }
 
+func (b *HTMLTagBuilder) RemoveClass(names ...string) (r *HTMLTagBuilder) {
+	remove := splitClassNames(names...)
+	var classNames []string
+	for _, n := range b.classNames {
+		if !containsString(remove, n) {
+			classNames = append(classNames, n)
+		}
+	}
+	b.classNames = classNames
+	return b
+}
+
+// ToggleClass adds the class name if the builder does not have it, and removes it otherwise.
+func (b *HTMLTagBuilder) ToggleClass(name string) (r *HTMLTagBuilder) {
+	if b.HasClass(name) {
+		return b.RemoveClass(name)
+	}
+	return b.addClass(name)
+}
+
+// ReplaceClass replaces the class name old with new, keeping its position, and does nothing if the builder does not have old.
+func (b *HTMLTagBuilder) ReplaceClass(old string, new string) (r *HTMLTagBuilder) {
+	var classNames []string
+	for _, n := range b.classNames {
+		if n == old {
+			classNames = append(classNames, splitClassNames(new)...)
+			continue
+		}
+		classNames = append(classNames, n)
+	}
+	b.classNames = classNames
+	return b
+}
+
+func containsString(vs []string, v string) bool {
+	for _, s := range vs {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *HTMLTagBuilder) Data(vs ...string) (r *HTMLTagBuilder) {
 	for i := 0; i < len(vs); i = i + 2 {
 		b.Attr(fmt.Sprintf("data-%s", vs[i]), vs[i+1])
@@ -138,7 +207,7 @@ func (b *HTMLTagBuilder) Rel(v string) (r *HTMLTagBuilder) {
 }
 
 func (b *HTMLTagBuilder) Title(v string) (r *HTMLTagBuilder) {
-	b.Attr("title", html.EscapeString(v))
+	b.Attr("title", v)
 	return b
 }
 
@@ -208,6 +277,45 @@ func (b *HTMLTagBuilder) addStyle(v string) (r *HTMLTagBuilder) {
 	return b
 }
 
+// SetStyleProperty sets the css property prop to value, replacing any declaration of prop added before.
+func (b *HTMLTagBuilder) SetStyleProperty(prop string, value string) (r *HTMLTagBuilder) {
+	b.RemoveStyleProperty(prop)
+	return b.addStyle(prop + ":" + value)
+}
+
+// RemoveStyleProperty removes every declaration of the css property prop added with Style, leaving the other declarations as they were added.
+func (b *HTMLTagBuilder) RemoveStyleProperty(prop string) (r *HTMLTagBuilder) {
+	prop = strings.ToLower(strings.TrimSpace(prop))
+	var styles []string
+	for _, s := range b.styles {
+		var kept []string
+		removed := false
+		for _, decl := range splitCSSDeclarations(s) {
+			decl = strings.TrimSpace(decl)
+			if len(decl) == 0 {
+				continue
+			}
+			name := decl
+			if i := strings.IndexByte(decl, ':'); i >= 0 {
+				name = decl[:i]
+			}
+			if strings.ToLower(strings.TrimSpace(name)) == prop {
+				removed = true
+				continue
+			}
+			kept = append(kept, decl)
+		}
+		switch {
+		case !removed:
+			styles = append(styles, s)
+		case len(kept) > 0:
+			styles = append(styles, strings.Join(kept, "; "))
+		}
+	}
+	b.styles = styles
+	return b
+}
+
 func (b *HTMLTagBuilder) Type(v string) (r *HTMLTagBuilder) {
 	b.Attr("type", v)
 	return b
@@ -223,6 +331,16 @@ func (b *HTMLTagBuilder) Src(v string) (r *HTMLTagBuilder) {
 	return b
 }
 
+func (b *HTMLTagBuilder) Integrity(v string) (r *HTMLTagBuilder) {
+	b.Attr("integrity", v)
+	return b
+}
+
+func (b *HTMLTagBuilder) CrossOrigin(v string) (r *HTMLTagBuilder) {
+	b.Attr("crossorigin", v)
+	return b
+}
+
 func (b *HTMLTagBuilder) Property(v string) (r *HTMLTagBuilder) {
 	b.Attr("property", v)
 	return b
@@ -268,6 +386,54 @@ func (b *HTMLTagBuilder) PrependChildren(c ...HTMLComponent) (r *HTMLTagBuilder)
 	return b
 }
 
+func (b *HTMLTagBuilder) ClearChildren() (r *HTMLTagBuilder) {
+	b.children = nil
+	return b
+}
+
+func (b *HTMLTagBuilder) TagName() (r string) {
+	return b.tag
+}
+
+// GetAttr returns the value of attribute k as set with Attr or the typed setters, "class" and "style" include what Class and Style added.
+func (b *HTMLTagBuilder) GetAttr(k string) (v interface{}, ok bool) {
+	for _, at := range b.mergedAttrs() {
+		if at.key == k {
+			return at.value, true
+		}
+	}
+	return
+}
+
+func (b *HTMLTagBuilder) HasClass(name string) bool {
+	for _, n := range b.classNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Classes returns a copy of the class names added with Class and ClassIf.
+func (b *HTMLTagBuilder) Classes() (r []string) {
+	return append(r, b.classNames...)
+}
+
+// Styles returns a copy of the declarations added with Style and StyleIf.
+func (b *HTMLTagBuilder) Styles() (r []string) {
+	return append(r, b.styles...)
+}
+
+// GetChildren returns a copy of the children, changing the slice does not change the builder.
+func (b *HTMLTagBuilder) GetChildren() (r []HTMLComponent) {
+	return append(r, b.children...)
+}
+
+// IsVoid reports whether the element is written without an end tag, like the ones made by Br, Img or Input.
+func (b *HTMLTagBuilder) IsVoid() bool {
+	return b.omitEndTag
+}
+
 var bufPool = sync.Pool{
 	New: func() any {
 		return &bytes.Buffer{}
@@ -275,14 +441,28 @@ var bufPool = sync.Pool{
 }
 
 func (b *HTMLTagBuilder) MarshalHTML(ctx context.Context) (r []byte, err error) {
-	class := strings.TrimSpace(strings.Join(b.classNames, " "))
-	if len(class) > 0 {
-		b.Attr("class", class)
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+
+	err = b.WriteHTML(ctx, buf)
+	if err != nil {
+		return
 	}
+	r = make([]byte, buf.Len())
+	copy(r, buf.Bytes())
+	return
+}
 
-	styles := strings.TrimSpace(strings.Join(b.styles, "; "))
-	if len(styles) > 0 {
-		b.Attr("style", styles+";")
+// WriteHTML only reads the builder, so a builder that is no longer modified can be rendered from many goroutines at once.
+func (b *HTMLTagBuilder) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	attrStr, err := b.attrString(ctx)
+	if err != nil {
+		return
+	}
+
+	if cfg, ok := renderConfigFrom(ctx); ok {
+		return b.writeFormatted(ctx, w, cfg, attrStr)
 	}
 
 	// remove empty
@@ -294,79 +474,166 @@ func (b *HTMLTagBuilder) MarshalHTML(ctx context.Context) (r []byte, err error)
 		cs = append(cs, c)
 	}
 
+	newline := ""
+
+	if b.omitEndTag {
+		newline = "\n"
+	}
+	_, err = io.WriteString(w, fmt.Sprintf("\n<%s%s>%s", b.tag, attrStr, newline))
+	if err != nil {
+		return
+	}
+	if !b.omitEndTag {
+		for _, c := range cs {
+			err = writeHTML(ctx, w, c)
+			if err != nil {
+				return
+			}
+		}
+		_, err = io.WriteString(w, fmt.Sprintf("</%s>\n", b.tag))
+	}
+	return
+}
+
+func (b *HTMLTagBuilder) attrString(ctx context.Context) (r string, err error) {
 	var attrSegs []string
-	for _, at := range b.attrs {
-		var val string
-		var isBool bool
-		var boolVal bool
-		switch v := at.value.(type) {
-		case string:
-			val = v
-		case []byte:
-			val = string(v)
-		case []rune:
-			val = string(v)
-		case int, int8, int16, int32, int64,
-			uint, uint8, uint16, uint32, uint64:
-			val = fmt.Sprintf(`%d`, v)
-		case float32, float64:
-			val = fmt.Sprintf(`%f`, v)
-		case bool:
-			boolVal = v
-			isBool = true
-		default:
-			val = JSONString(v)
+	for _, at := range b.renderAttrs(ctx) {
+		if !isValidAttrName(at.key) {
+			err = fmt.Errorf("htmlgo: invalid attribute name %q on <%s>", at.key, b.tag)
+			return
 		}
 
-		if len(val) == 0 && !isBool {
+		val, ok, untrusted := attrValueString(at.value)
+		if !ok {
 			continue
 		}
 
-		if isBool && !boolVal {
+		if _, isBool := at.value.(bool); isBool {
+			attrSegs = append(attrSegs, at.key)
 			continue
 		}
 
-		seg := fmt.Sprintf(`%s='%s'`, escapeAttr(at.key), escapeAttr(val))
-		if isBool && boolVal {
-			seg = escapeAttr(at.key)
+		if untrusted {
+			if attrContextOf(at.key) == attrContextJS {
+				err = fmt.Errorf("htmlgo: event handler %s on <%s> needs a SafeJS value, not a plain string, use SafeJS for trusted code or JSCall to pass untrusted values", at.key, b.tag)
+				return
+			}
+			val = escapeAttrContext(at.key, val)
 		}
-		attrSegs = append(attrSegs, seg)
+
+		attrSegs = append(attrSegs, fmt.Sprintf(`%s='%s'`, at.key, escapeAttr(val)))
 	}
 
-	attrStr := ""
 	if len(attrSegs) > 0 {
-		attrStr = " " + strings.Join(attrSegs, " ")
+		r = " " + strings.Join(attrSegs, " ")
 	}
+	return
+}
 
-	buf := bufPool.Get().(*bytes.Buffer)
-	defer bufPool.Put(buf)
-	buf.Reset()
+// attrValueString returns the text of an attr value before escaping, ok is false for values that are not written, like "" or false
+func attrValueString(value interface{}) (val string, ok bool, untrusted bool) {
+	switch v := value.(type) {
+	case string:
+		val = v
+		untrusted = true
+	case []byte:
+		val = string(v)
+		untrusted = true
+	case []rune:
+		val = string(v)
+		untrusted = true
+	case SafeURL:
+		val = string(v)
+	case SafeJS:
+		val = string(v)
+	case SafeCSS:
+		val = string(v)
+	case int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		val = fmt.Sprintf(`%d`, v)
+	case float32, float64:
+		val = fmt.Sprintf(`%f`, v)
+	case bool:
+		return "", v, false
+	default:
+		val = JSONString(v)
+	}
+	ok = len(val) > 0
+	return
+}
 
-	newline := ""
+// renderAttrs returns attrs with class, style and the csp nonce merged in, leaving b.attrs untouched
+func (b *HTMLTagBuilder) renderAttrs(ctx context.Context) (attrs []*tagAttr) {
+	attrs = b.mergedAttrs()
 
-	if b.omitEndTag {
-		newline = "\n"
+	if b.takesNonce() {
+		if nonce, ok := CSPNonce(ctx); ok && !hasAttr(attrs, "nonce") {
+			attrs = replaceAttr(attrs, "nonce", nonce)
+		}
 	}
-	buf.WriteString(fmt.Sprintf("\n<%s%s>%s", b.tag, attrStr, newline))
-	if !b.omitEndTag {
-		if len(cs) > 0 {
-			// buf.WriteString("\n")
-			for _, c := range cs {
-				var child []byte
-				child, err = c.MarshalHTML(ctx)
-				if err != nil {
-					return
-				}
-				buf.Write(child)
+	return
+}
+
+// takesNonce tells if the element is allowed by a csp nonce, scripts, styles and the stylesheets and preloads linked
+func (b *HTMLTagBuilder) takesNonce() bool {
+	switch b.tag {
+	case "script", "style":
+		return true
+	case "link":
+		rel, _ := writtenAttr(b, "rel")
+		for _, r := range strings.Fields(strings.ToLower(rel)) {
+			if r == "stylesheet" || r == "preload" || r == "modulepreload" {
+				return true
 			}
 		}
-		buf.WriteString(fmt.Sprintf("</%s>\n", b.tag))
 	}
-	r = make([]byte, buf.Len())
-	copy(r, buf.Bytes())
+	return false
+}
+
+// mergedAttrs returns attrs with class and style merged in, as they are rendered
+func (b *HTMLTagBuilder) mergedAttrs() (attrs []*tagAttr) {
+	attrs = b.attrs
+
+	class := strings.TrimSpace(strings.Join(b.classNames, " "))
+	if len(class) > 0 {
+		attrs = replaceAttr(attrs, "class", class)
+	}
+
+	styles := strings.TrimSpace(strings.Join(b.styles, "; "))
+	if len(styles) > 0 {
+		var style interface{} = styles + ";"
+		// a trusted style attr stays trusted, only the styles added to it are filtered
+		for _, at := range attrs {
+			if safe, ok := at.value.(SafeCSS); ok && at.key == "style" {
+				style = SafeCSS(strings.TrimRight(strings.TrimSpace(string(safe)), ";") + "; " + filterCSS(styles+";"))
+			}
+		}
+		attrs = replaceAttr(attrs, "style", style)
+	}
 	return
 }
 
+func hasAttr(attrs []*tagAttr, k string) bool {
+	for _, at := range attrs {
+		if at.key == k {
+			return true
+		}
+	}
+	return false
+}
+
+func replaceAttr(attrs []*tagAttr, k string, v interface{}) (r []*tagAttr) {
+	r = make([]*tagAttr, len(attrs), len(attrs)+1)
+	copy(r, attrs)
+	for i, at := range r {
+		if at.key == k {
+			r[i] = &tagAttr{k, v}
+			return
+		}
+	}
+	return append(r, &tagAttr{k, v})
+}
+
 func JSONString(v interface{}) (r string) {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -376,8 +643,28 @@ func JSONString(v interface{}) (r string) {
 	return
 }
 
+var attrValueReplacer = strings.NewReplacer(
+	"&", "&amp;",
+	"'", "&#39;",
+)
+
+// escapeAttr escapes a value written inside single quotes, so browsers read back exactly the characters it holds
 func escapeAttr(str string) (r string) {
-	r = strings.Replace(str, "'", "&#39;", -1)
-	//r = strings.Replace(r, "\n", "", -1)
-	return
+	return attrValueReplacer.Replace(str)
+}
+
+// isValidAttrName reports whether k can be written as an attribute name, following https://html.spec.whatwg.org/#attributes-2
+func isValidAttrName(k string) bool {
+	if len(k) == 0 || !utf8.ValidString(k) {
+		return false
+	}
+	for _, c := range k {
+		switch {
+		case c <= 0x20, c >= 0x7f && c <= 0x9f:
+			return false
+		case c == '"', c == '\'', c == '>', c == '<', c == '/', c == '=':
+			return false
+		}
+	}
+	return true
 }
@@ -1,7 +1,10 @@
 package htmlgo_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"sync"
 	"testing"
 
 	. "github.com/theplant/htmlgo"
@@ -49,7 +52,18 @@ var htmltagCases = []struct {
 		),
 		expected: `
 <div>
-<div class='menu' id='the><&"&#39;-menu'>Hello</div>
+<div class='menu' id='the><&amp;"&#39;-menu'>Hello</div>
+</div>
+`,
+	},
+	{
+		name: "escape entities",
+		tag: Div(
+			A().Href("/list?a=1&copy=2").Title("Tom & Jerry&#39;s"),
+		),
+		expected: `
+<div>
+<a href='/list?a=1&amp;copy=2' title='Tom &amp; Jerry&amp;#39;s'></a>
 </div>
 `,
 	},
@@ -67,3 +81,196 @@ func TestHtmlTag(t *testing.T) {
 		}
 	}
 }
+
+func TestInvalidAttrName(t *testing.T) {
+	for _, name := range []string{"", "a b", "x='y'", "on\x00click", "a>b", "data/x", "\xff"} {
+		_, err := Div().Attr(name, "v").MarshalHTML(context.TODO())
+		if err == nil {
+			t.Errorf("expected error for attribute name %q", name)
+		}
+	}
+
+	_, err := Div().Attr("data-x", "v", "@click", "go()", ":class", "c", "aria-label", "l").MarshalHTML(context.TODO())
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+type marshalOnly struct{}
+
+func (marshalOnly) MarshalHTML(ctx context.Context) ([]byte, error) {
+	return []byte("<i>marshalled</i>"), nil
+}
+
+func TestWriteHTML(t *testing.T) {
+	for _, c := range htmltagCases {
+		buf := bytes.NewBuffer(nil)
+		err := c.tag.WriteHTML(context.TODO(), buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff := testingutils.PrettyJsonDiff(c.expected, buf.String())
+		if len(diff) > 0 {
+			t.Error(c.name, diff)
+		}
+	}
+
+	comp := Div(
+		marshalOnly{},
+		If(true, Span("if")),
+		Iff(true, func() HTMLComponent { return Span("iff") }),
+		ComponentFunc(func(ctx context.Context) ([]byte, error) {
+			return []byte("func"), nil
+		}),
+	)
+	expected := MustString(comp, context.TODO())
+	marshalled, err := comp.MarshalHTML(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff := testingutils.PrettyJsonDiff(string(marshalled), expected)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestFprintWritesNothingOnError(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	err := Fprint(buf, Div(Span("ok"), ComponentFunc(func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("boom")
+	})), context.TODO())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if buf.Len() > 0 {
+		t.Errorf("output of a failed render was written: %q", buf.String())
+	}
+}
+
+var sharedLayout = Div(
+	Nav(A(Text("Home")).Href("/")).Class("nav"),
+	Div(Span("content")).Class("main").Style("color:red"),
+).Class("layout").Style("margin:0")
+
+func TestConcurrentMarshalHTML(t *testing.T) {
+	expected := MustString(sharedLayout, context.TODO())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				r, err := sharedLayout.MarshalHTML(context.TODO())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if string(r) != expected {
+					t.Errorf("expected %q, got %q", expected, r)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMarshalHTMLDoesNotMutate(t *testing.T) {
+	b := Div().Class("a").Style("color:red")
+	MustString(b, context.TODO())
+	b.Attr("id", "x")
+
+	expected := MustString(Div().Class("a").Style("color:red").Attr("id", "x"), context.TODO())
+	r := MustString(b, context.TODO())
+	if r != expected {
+		t.Errorf("expected %q, got %q", expected, r)
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	b := Div(Span("a"), nil).
+		Id("main").
+		Class("box big").
+		Attr("data-n", 3).
+		Style("color:red").
+		Style("margin:0;")
+
+	if b.TagName() != "div" || b.IsVoid() || !Br().IsVoid() {
+		t.Error("unexpected tag name or void")
+	}
+	if v, ok := b.GetAttr("id"); !ok || v != "main" {
+		t.Errorf("unexpected id %v", v)
+	}
+	if v, ok := b.GetAttr("data-n"); !ok || v != 3 {
+		t.Errorf("unexpected data-n %v", v)
+	}
+	if v, _ := b.GetAttr("class"); v != "box big" {
+		t.Errorf("unexpected class %v", v)
+	}
+	if _, ok := b.GetAttr("title"); ok {
+		t.Error("unexpected title")
+	}
+	if !b.HasClass("big") || b.HasClass("bi") {
+		t.Error("unexpected HasClass")
+	}
+
+	classes := b.Classes()
+	classes[0] = "changed"
+	diff := testingutils.PrettyJsonDiff([]string{"box", "big"}, b.Classes())
+	diff += testingutils.PrettyJsonDiff([]string{"color:red", "margin:0"}, b.Styles())
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+
+	children := b.GetChildren()
+	if len(children) != 2 || children[0].(*HTMLTagBuilder).TagName() != "span" {
+		t.Errorf("unexpected children %#+v", children)
+	}
+}
+
+func TestRemoveAndToggle(t *testing.T) {
+	b := Div(Span("a")).
+		Id("main").
+		Title("t").
+		Attr("data-x", "1").
+		Class("a b c b").
+		Style("color:red; margin:0").
+		Style("Color: blue")
+
+	b.RemoveAttr("title", "data-x", "missing").
+		RemoveClass("b", "x").
+		ToggleClass("a").
+		ToggleClass("d").
+		ReplaceClass("c", "e f").
+		ReplaceClass("missing", "g").
+		SetStyleProperty("color", "green").
+		SetStyleProperty("padding", "1px").
+		RemoveStyleProperty("margin").
+		ClearChildren().
+		AppendChildren(Text("new"))
+
+	expected := "\n<div id='main' class='e f d' style='color:green; padding:1px;'>new</div>\n"
+	r := MustString(b, context.TODO())
+	if r != expected {
+		t.Errorf("expected %q, got %q", expected, r)
+	}
+
+	// semicolons inside url() and strings do not split declarations, and untouched ones are kept as added
+	styled := Div().
+		Style("background:url('https://cdn/x;v=1.png') no-repeat; top:0").
+		Style(`content:";"`).
+		SetStyleProperty("top", "1px")
+	expected = "\n<div style='background:url(&#39;https://cdn/x;v=1.png&#39;) no-repeat; content:\";\"; top:1px;'></div>\n"
+	r = MustString(styled, context.TODO())
+	if r != expected {
+		t.Errorf("expected %q, got %q", expected, r)
+	}
+
+	b.RemoveAttr("class", "style")
+	expected = "\n<div id='main'>new</div>\n"
+	r = MustString(b, context.TODO())
+	if r != expected {
+		t.Errorf("expected %q, got %q", expected, r)
+	}
+}
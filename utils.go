@@ -15,6 +15,11 @@ func (s RawHTML) MarshalHTML(ctx context.Context) (r []byte, err error) {
 	return
 }
 
+func (s RawHTML) WriteHTML(ctx context.Context, w io.Writer) (err error) {
+	_, err = io.WriteString(w, string(s))
+	return
+}
+
 func Text(text string) (r HTMLComponent) {
 	return RawHTML(html.EscapeString(text))
 }
@@ -31,31 +36,54 @@ func Components(comps ...HTMLComponent) HTMLComponents {
 
 func (hcs HTMLComponents) MarshalHTML(ctx context.Context) (r []byte, err error) {
 	buf := bytes.NewBuffer(nil)
+	err = hcs.WriteHTML(ctx, buf)
+	if err != nil {
+		return
+	}
+	r = buf.Bytes()
+	return
+}
+
+func (hcs HTMLComponents) WriteHTML(ctx context.Context, w io.Writer) (err error) {
 	for _, h := range hcs {
-		if h == nil {
-			continue
-		}
-		var b []byte
-		b, err = h.MarshalHTML(ctx)
+		err = writeHTML(ctx, w, h)
 		if err != nil {
 			return
 		}
-		buf.Write(b)
 	}
-	r = buf.Bytes()
 	return
 }
 
+/*
+Fprint renders root into w. The whole tree is written into one buffer, components implementing HTMLWriter directly, others with MarshalHTML,
+and the buffer is written to w once rendering succeeded, so nothing is written on error. Use Stream to send output while rendering.
+*/
 func Fprint(w io.Writer, root HTMLComponent, ctx context.Context) (err error) {
-	if root == nil {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+
+	err = writeHTML(ctx, buf, root)
+	if err != nil {
+		return
+	}
+	_, err = w.Write(buf.Bytes())
+	return
+}
+
+func writeHTML(ctx context.Context, w io.Writer, c HTMLComponent) (err error) {
+	if c == nil {
 		return
 	}
+	if hw, ok := c.(HTMLWriter); ok {
+		return hw.WriteHTML(ctx, w)
+	}
 	var b []byte
-	b, err = root.MarshalHTML(ctx)
+	b, err = c.MarshalHTML(ctx)
 	if err != nil {
 		return
 	}
-	_, err = fmt.Fprint(w, string(b))
+	_, err = w.Write(b)
 	return
 }
 
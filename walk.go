@@ -0,0 +1,140 @@
+package htmlgo
+
+/*
+ChildrenProvider is implemented by components holding other components, so Walk and Transform can descend into them.
+*HTMLTagBuilder implements it, implement it on your own components to make their children visible.
+*/
+type ChildrenProvider interface {
+	GetChildren() []HTMLComponent
+}
+
+// ChildrenReplacer lets Transform replace the children of your own components, see Transform.
+type ChildrenReplacer interface {
+	ChildrenProvider
+	ReplaceChildren(children []HTMLComponent)
+}
+
+type WalkAction int
+
+const (
+	// WalkContinue visits the children of the node, then its next sibling
+	WalkContinue WalkAction = iota
+	// WalkSkipChildren goes on with the next sibling without visiting the children of the node
+	WalkSkipChildren
+	// WalkStop ends the walk
+	WalkStop
+)
+
+/*
+Walk calls fn for root and every component below it, depth first, with the ancestors of each node from root down to its parent in path.
+It descends through HTMLTagBuilder children, HTMLComponents, the selected branch of If, Parallel, Compact and HTML children,
+and components implementing ChildrenProvider. The result of Iff and ComponentFunc is only known when rendering, so they are not entered.
+
+	Walk(page, func(node HTMLComponent, path []HTMLComponent) WalkAction {
+		if b, ok := node.(*HTMLTagBuilder); ok && b.TagName() == "img" {
+			b.Attr("loading", "lazy")
+		}
+		return WalkContinue
+	})
+*/
+func Walk(root HTMLComponent, fn func(node HTMLComponent, path []HTMLComponent) WalkAction) {
+	walk(root, nil, fn)
+}
+
+func walk(node HTMLComponent, path []HTMLComponent, fn func(node HTMLComponent, path []HTMLComponent) WalkAction) (stop bool) {
+	if node == nil {
+		return
+	}
+	switch fn(node, path) {
+	case WalkStop:
+		return true
+	case WalkSkipChildren:
+		return
+	}
+
+	// a new array for every level, so fn can keep the path it was given
+	path = append(path[:len(path):len(path)], node)
+	for _, c := range childrenOf(node) {
+		if walk(c, path, fn) {
+			return true
+		}
+	}
+	return
+}
+
+/*
+Transform calls fn for root and every component below it like Walk does, and puts the component fn returns in place of the node,
+or removes the node when fn returns nil. The children of the returned component are transformed next.
+Builders are changed in place, Clone a shared tree first. Children of your own components are only replaced if they implement ChildrenReplacer.
+
+	page = Transform(page, func(node HTMLComponent, path []HTMLComponent) HTMLComponent {
+		if b, ok := node.(*HTMLTagBuilder); ok && b.TagName() == "blink" {
+			return Span("").Children(b.GetChildren()...)
+		}
+		return node
+	})
+*/
+func Transform(root HTMLComponent, fn func(node HTMLComponent, path []HTMLComponent) HTMLComponent) (r HTMLComponent) {
+	return transform(root, nil, fn)
+}
+
+func transform(node HTMLComponent, path []HTMLComponent, fn func(node HTMLComponent, path []HTMLComponent) HTMLComponent) (r HTMLComponent) {
+	if node == nil {
+		return
+	}
+	r = fn(node, path)
+	if r == nil {
+		return
+	}
+
+	children := childrenOf(r)
+	if len(children) == 0 {
+		return
+	}
+	path = append(path[:len(path):len(path)], r)
+	var transformed []HTMLComponent
+	for _, c := range children {
+		if tc := transform(c, path, fn); tc != nil {
+			transformed = append(transformed, tc)
+		}
+	}
+	return withChildren(r, transformed)
+}
+
+func childrenOf(c HTMLComponent) (r []HTMLComponent) {
+	switch v := c.(type) {
+	case HTMLComponents:
+		return v
+	case *IfBuilder:
+		return v.comps
+	case *ParallelBuilder:
+		return v.children
+	case compactComponents:
+		return v
+	case headScope:
+		return v
+	case ChildrenProvider:
+		return v.GetChildren()
+	}
+	return
+}
+
+func withChildren(c HTMLComponent, children []HTMLComponent) (r HTMLComponent) {
+	switch v := c.(type) {
+	case HTMLComponents:
+		return HTMLComponents(children)
+	case *HTMLTagBuilder:
+		v.children = children
+	case *IfBuilder:
+		v.comps = children
+	case *ParallelBuilder:
+		v.children = children
+	case compactComponents:
+		return compactComponents(children)
+	case headScope:
+		return headScope(children)
+	case ChildrenReplacer:
+		v.ReplaceChildren(children)
+	}
+	return c
+}
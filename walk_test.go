@@ -0,0 +1,126 @@
+package htmlgo_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/theplant/htmlgo"
+	"github.com/theplant/testingutils"
+)
+
+type cardBuilder struct {
+	body []HTMLComponent
+}
+
+func (b *cardBuilder) GetChildren() []HTMLComponent {
+	return b.body
+}
+
+func (b *cardBuilder) ReplaceChildren(children []HTMLComponent) {
+	b.body = children
+}
+
+func (b *cardBuilder) MarshalHTML(ctx context.Context) ([]byte, error) {
+	return Div(b.body...).Class("card").MarshalHTML(ctx)
+}
+
+func walkPage() HTMLComponent {
+	return Components(
+		Div(
+			Img("/a.png").Id("a"),
+			If(false, Img("/hidden.png").Id("hidden")).Else(Img("/b.png").Id("b")),
+		).Id("top"),
+		&cardBuilder{body: []HTMLComponent{Img("/c.png").Id("a"), nil}},
+		Compact(Span("x").Id("s")),
+	)
+}
+
+func TestWalk(t *testing.T) {
+	var ids []string
+	var paths []string
+	Walk(walkPage(), func(node HTMLComponent, path []HTMLComponent) WalkAction {
+		b, ok := node.(*HTMLTagBuilder)
+		if !ok {
+			return WalkContinue
+		}
+		if id, ok := b.GetAttr("id"); ok {
+			ids = append(ids, id.(string))
+		}
+		if b.TagName() == "img" {
+			b.Attr("loading", "lazy")
+			var tags []string
+			for _, p := range path {
+				if pb, ok := p.(*HTMLTagBuilder); ok {
+					tags = append(tags, pb.TagName())
+				}
+			}
+			paths = append(paths, strings.Join(tags, ">"))
+		}
+		return WalkContinue
+	})
+
+	diff := testingutils.PrettyJsonDiff([]string{"top", "a", "b", "a", "s"}, ids)
+	diff += testingutils.PrettyJsonDiff([]string{"div", "div", ""}, paths)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestWalkSkipAndStop(t *testing.T) {
+	var visited []string
+	Walk(walkPage(), func(node HTMLComponent, path []HTMLComponent) WalkAction {
+		b, ok := node.(*HTMLTagBuilder)
+		if !ok {
+			return WalkContinue
+		}
+		id, _ := b.GetAttr("id")
+		visited = append(visited, id.(string))
+		switch id {
+		case "top":
+			return WalkSkipChildren
+		case "a":
+			return WalkStop
+		}
+		return WalkContinue
+	})
+
+	diff := testingutils.PrettyJsonDiff([]string{"top", "a"}, visited)
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	page := Transform(walkPage(), func(node HTMLComponent, path []HTMLComponent) HTMLComponent {
+		b, ok := node.(*HTMLTagBuilder)
+		if !ok {
+			return node
+		}
+		switch b.TagName() {
+		case "img":
+			src, _ := b.GetAttr("src")
+			if src == "/b.png" {
+				return nil
+			}
+			return b.Src("https://cdn.example.com" + src.(string))
+		case "span":
+			return Strong("replaced")
+		}
+		return node
+	})
+
+	expected := `
+<div id='top'>
+<img src='https://cdn.example.com/a.png' id='a'>
+</div>
+
+<div class='card'>
+<img src='https://cdn.example.com/c.png' id='a'>
+</div>
+<strong>replaced</strong>`
+	diff := testingutils.PrettyJsonDiff(expected, MustString(page, context.TODO()))
+	if len(diff) > 0 {
+		t.Error(diff)
+	}
+}